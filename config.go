@@ -0,0 +1,78 @@
+// (c) Copyright 2021, Gorror Authors.
+//
+// Licensed under the terms of the GNU GPL License version 3.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// configName is the name of the configuration file looked up when -config is not given.
+const configName = ".gorror.json"
+
+// findConfig looks for the configuration file in dir and its parents, returning the path of
+// the nearest one.
+func findConfig(dir string) (string, bool) {
+	for {
+		path := filepath.Join(dir, configName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// applyConfig sets the flags from a JSON configuration file, whose keys are flag names (e.g.
+// {"type": "Err", "suffix": "Spec", "import": ["time"]}). Flags explicitly set on the command
+// line take precedence over the configuration, including through their aliases (e.g. -package
+// for -pkg), which share the value of the flag.
+func applyConfig(fs *flag.FlagSet, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var cfg map[string]interface{}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("parsing config %s: %w", path, err)
+	}
+
+	set := make(map[flag.Value]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Value] = true })
+	for name, value := range cfg {
+		f := fs.Lookup(name)
+		if f == nil {
+			return fmt.Errorf("config %s: unknown flag %q", path, name)
+		}
+		if set[f.Value] {
+			continue
+		}
+		if err := fs.Set(name, configValue(value)); err != nil {
+			return fmt.Errorf("config %s: flag %q: %w", path, name, err)
+		}
+	}
+	return nil
+}
+
+// configValue converts a JSON value into the textual value of a flag. Lists are joined with
+// commas, as expected by e.g. -import.
+func configValue(value interface{}) string {
+	list, ok := value.([]interface{})
+	if !ok {
+		return fmt.Sprint(value)
+	}
+	elems := make([]string, len(list))
+	for i, v := range list {
+		elems[i] = fmt.Sprint(v)
+	}
+	return strings.Join(elems, ",")
+}
@@ -0,0 +1,115 @@
+// (c) Copyright 2021, Gorror Authors.
+//
+// Licensed under the terms of the GNU GPL License version 3.
+
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyConfig(t *testing.T) {
+	cfgFile := filepath.Join(t.TempDir(), "gorror.json")
+	cfg := `{"type": "Err", "suffix": "Spec", "import": ["time", "net"], "P": true}`
+	if err := os.WriteFile(cfgFile, []byte(cfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := flag.NewFlagSet("gorror", flag.ContinueOnError)
+	typ := fs.String("type", "", "")
+	suffix := fs.String("suffix", "", "")
+	imports := fs.String("import", "", "")
+	pub := fs.Bool("P", false, "")
+	if err := fs.Parse([]string{"-suffix", "Def"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyConfig(fs, cfgFile); err != nil {
+		t.Fatal(err)
+	}
+	if *typ != "Err" {
+		t.Errorf("type: got %q, expected %q", *typ, "Err")
+	}
+	if *suffix != "Def" {
+		t.Errorf("suffix: got %q, expected command line value %q", *suffix, "Def")
+	}
+	if *imports != "time,net" {
+		t.Errorf("import: got %q, expected %q", *imports, "time,net")
+	}
+	if !*pub {
+		t.Error("P: expected true")
+	}
+}
+
+func TestApplyConfigAlias(t *testing.T) {
+	cfgFile := filepath.Join(t.TempDir(), "gorror.json")
+	if err := os.WriteFile(cfgFile, []byte(`{"pkg": "config", "verbose": false}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := flag.NewFlagSet("gorror", flag.ContinueOnError)
+	pkg := fs.String("pkg", "", "")
+	fs.StringVar(pkg, "package", "", "")
+	var verbose bool
+	fs.BoolVar(&verbose, "v", false, "")
+	fs.BoolVar(&verbose, "verbose", false, "")
+	if err := fs.Parse([]string{"-package", "cli", "-v"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyConfig(fs, cfgFile); err != nil {
+		t.Fatal(err)
+	}
+	if *pkg != "cli" {
+		t.Errorf("pkg: got %q, expected command line value %q of -package", *pkg, "cli")
+	}
+	if !verbose {
+		t.Error("verbose: expected command line value true of -v")
+	}
+}
+
+func TestApplyConfigUnknownFlag(t *testing.T) {
+	cfgFile := filepath.Join(t.TempDir(), "gorror.json")
+	if err := os.WriteFile(cfgFile, []byte(`{"typo": "Err"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fs := flag.NewFlagSet("gorror", flag.ContinueOnError)
+	fs.String("type", "", "")
+	if err := applyConfig(fs, cfgFile); err == nil {
+		t.Error("expected error for unknown flag")
+	}
+}
+
+func TestFindConfig(t *testing.T) {
+	root := t.TempDir()
+	cfgFile := filepath.Join(root, configName)
+	if err := os.WriteFile(cfgFile, []byte(`{"type": "Err", "P": true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(root, "pkg", "errs")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	path, ok := findConfig(dir)
+	if !ok || path != cfgFile {
+		t.Fatalf("got %q, %t, expected %q", path, ok, cfgFile)
+	}
+	fs := flag.NewFlagSet("gorror", flag.ContinueOnError)
+	typ := fs.String("type", "", "")
+	pub := fs.Bool("P", false, "")
+	if err := fs.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyConfig(fs, path); err != nil {
+		t.Fatal(err)
+	}
+	if *typ != "Err" || !*pub {
+		t.Errorf("got type %q and P %t, expected the defaults of the config", *typ, *pub)
+	}
+
+	if path, ok := findConfig(t.TempDir()); ok {
+		t.Errorf("got %q, expected no config", path)
+	}
+}
@@ -5,14 +5,146 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"go/build"
 	"io"
+	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
+// endToEndFlags lists additional flags to pass to gorror for a given testdata file.
+var endToEndFlags = map[string][]string{
+	"ashelpers.go":   {"-as-helpers"},
+	"cachemsg.go":    {"-cache-msg"},
+	"compat.go":      {"-is", "-goimports"},
+	"causer.go":      {"-causer"},
+	"context.go":     {"-context", "-fmt-modes", "-cli-method", "-cli-color", "", "-gostring"},
+	"ctormap.go":     {"-ctor-map"},
+	"equal.go":       {"-equal"},
+	"fmtmodes.go":    {"-fmt-modes"},
+	"importalias.go": {"-import", "tm=time"},
+	"immutable.go":   {"-immutable"},
+	"reqid.go":       {"-reqid", "reqIDKey{}"},
+	"retiface.go":    {"-return-interface"},
+	"intenum.go":     {"-type", "Code"},
+	"isfunc.go":      {"-is-func"},
+	"iface.go":       {"-interface", "DomainError", "-domain", "storage"},
+	"logonce.go":     {"-log-once"},
+	"matchfields.go": {"-is", "-match-fields", "-goimports"},
+	"samekind.go":    {"-test-helpers"},
+	"nois.go":        {"-no-is"},
+	"opt.go":         {"-fmt-modes"},
+	"options.go":     {"-options"},
+	"sentinels.go":   {"-sentinels", "-is"},
+	"short.go":       {"-short", "-fmt-modes"},
+	"stack.go":       {"-stack"},
+	"stringer.go":    {"-stringer"},
+	"suppressed.go":  {"-suppressed"},
+	"text.go":        {"-text"},
+	"wraptype.go":    {"-wrap-type", "errCause"},
+}
+
+// endToEndRelease lists the Go release needed to run a given testdata file, when it relies on
+// newer standard library behavior (e.g. errors.Is traversing Unwrap() []error).
+var endToEndRelease = map[string]string{
+	"logonce.go":    "go1.19",
+	"multiwrap.go":  "go1.20",
+	"suppressed.go": "go1.20",
+}
+
+// hasRelease reports whether the Go toolchain supports the given release (e.g. go1.20).
+func hasRelease(release string) bool {
+	for _, tag := range build.Default.ReleaseTags {
+		if tag == release {
+			return true
+		}
+	}
+	return false
+}
+
+// TestInProcess is the primary correctness check: it generates the errors of each testdata file
+// in-process, without building and running the gorror binary, then runs the file with them.
+func TestInProcess(t *testing.T) {
+	defer resetFlags()
+	entries, err := os.ReadDir("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		t.Run(strings.TrimSuffix(name, ".go"), func(t *testing.T) {
+			if release, ok := endToEndRelease[name]; ok && !hasRelease(release) {
+				t.Skipf("%s requires %s", name, release)
+			}
+			tmpdir := t.TempDir()
+			source := filepath.Join(tmpdir, name)
+			if err := copyFile(source, filepath.Join("testdata", name)); err != nil {
+				t.Fatalf("copying file to temporary directory: %s", err)
+			}
+			errorsSource := filepath.Join(tmpdir, "errors.go")
+			args := append([]string{"-type", "Err", "-output", errorsSource}, endToEndFlags[name]...)
+			resetFlags()
+			if err := flag.CommandLine.Parse(append(args, source)); err != nil {
+				t.Fatal(err)
+			}
+			if err := execute(flag.Args()); err != nil {
+				t.Fatal(err)
+			}
+			if err := run("go", "run", errorsSource, source); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// TestExecuteError checks that execute reports failures instead of exiting, so that they fail
+// the in-process tests only.
+func TestExecuteError(t *testing.T) {
+	defer resetFlags()
+	source := filepath.Join(t.TempDir(), "bad.go")
+	src := "package main\n\ntype Err string\n\nconst ErrBad = Err(\"exit:256 failed\")\n"
+	if err := os.WriteFile(source, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"-type", "Err", "-wrap-type", "1x", source},
+		{"-type", "Err", source},
+	} {
+		resetFlags()
+		if err := flag.CommandLine.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+		if err := execute(flag.Args()); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
+
+// resetFlags sets the flags of gorror back to their default value, leaving the ones of the
+// testing package alone.
+func resetFlags() {
+	flag.VisitAll(func(f *flag.Flag) {
+		if !strings.HasPrefix(f.Name, "test.") {
+			f.Value.Set(f.DefValue)
+		}
+	})
+}
+
+// TestEndToEnd runs the gorror binary on each testdata file, like TestInProcess, covering the
+// command line as well. It is skipped in short mode.
 func TestEndToEnd(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping end-to-end test in short mode")
+	}
 	tmpdir, exePath := buildGorror(t)
 
 	entries, err := os.ReadDir("testdata")
@@ -22,6 +154,13 @@ func TestEndToEnd(t *testing.T) {
 
 	errorsSource := filepath.Join(tmpdir, "errors.go")
 	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if release, ok := endToEndRelease[entry.Name()]; ok && !hasRelease(release) {
+			t.Logf("skip: %s requires %s\n", entry.Name(), release)
+			continue
+		}
 		t.Logf("run: %s %s\n", exePath, entry.Name())
 		source := filepath.Join(tmpdir, entry.Name())
 		err = copyFile(source, filepath.Join("testdata", entry.Name()))
@@ -29,7 +168,8 @@ func TestEndToEnd(t *testing.T) {
 			t.Fatalf("copying file to temporary directory: %s", err)
 		}
 		// Run gorror in temporary directory.
-		err = run(exePath, "-type", "Err", "-output", errorsSource, source)
+		args := append([]string{"-type", "Err", "-output", errorsSource}, endToEndFlags[entry.Name()]...)
+		err = run(exePath, append(args, source)...)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -60,6 +200,35 @@ func run(name string, arg ...string) error {
 	return cmd.Run()
 }
 
+// writeTree writes the given files, keyed by their slash-separated path, to a temporary
+// directory and changes the working directory to it until the end of the test.
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, src := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Fatal(err)
+		}
+	})
+	return dir
+}
+
 func copyFile(to, from string) error {
 	toFd, err := os.Create(to)
 	if err != nil {
@@ -74,3 +243,362 @@ func copyFile(to, from string) error {
 	_, err = io.Copy(toFd, fromFd)
 	return err
 }
+
+func TestStdin(t *testing.T) {
+	tmpdir, exePath := buildGorror(t)
+	src := `package editor
+
+type Err string
+
+const (
+	ErrOpen = Err("failed to open {{file string %q}}")
+	ErrRead = Err("nowrap:failed to read")
+)`
+	for _, args := range [][]string{{"-"}, {"-stdin"}} {
+		cmd := exec.Command(exePath, append([]string{"-type", "Err"}, args...)...)
+		cmd.Dir = tmpdir
+		cmd.Stdin = strings.NewReader(src)
+		cmd.Stderr = os.Stderr
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, expected := range []string{"package editor", "type errOpen struct", "type errRead struct"} {
+			if !strings.Contains(string(out), expected) {
+				t.Errorf("%v: output does not contain %q:\n%s", args, expected, out)
+			}
+		}
+	}
+	entries, err := os.ReadDir(tmpdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("reading stdin created files: found %d entries in %s", len(entries), tmpdir)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	tmpdir, exePath := buildGorror(t)
+	srcDir := filepath.Join(tmpdir, "src")
+	if err := os.Mkdir(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := copyFile(filepath.Join(srcDir, "usage.go"), filepath.Join("testdata", "usage.go")); err != nil {
+		t.Fatalf("copying file to temporary directory: %s", err)
+	}
+
+	cmd := exec.Command(exePath, "-type", "Err", "-dry-run", filepath.Join(srcDir, "usage.go"))
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "type errOpen struct") {
+		t.Errorf("dry run output does not contain generated code:\n%s", out)
+	}
+	entries, err := os.ReadDir(srcDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("dry run created files: found %d entries in %s", len(entries), srcDir)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	tmpdir, exePath := buildGorror(t)
+	source := filepath.Join(tmpdir, "usage.go")
+	if err := copyFile(source, filepath.Join("testdata", "usage.go")); err != nil {
+		t.Fatalf("copying file to temporary directory: %s", err)
+	}
+	errorsSource := filepath.Join(tmpdir, "errors.go")
+	cfgFile := filepath.Join(tmpdir, "gorror.json")
+	cfg := fmt.Sprintf(`{"type": "Err", "output": %q}`, errorsSource)
+	if err := os.WriteFile(cfgFile, []byte(cfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := run(exePath, "-config", cfgFile, source); err != nil {
+		t.Fatal(err)
+	}
+	if err := run("go", "run", errorsSource, source); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tmpdir, exePath := buildGorror(t)
+	srcDir := filepath.Join(tmpdir, "src")
+	if err := os.Mkdir(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	source := filepath.Join(srcDir, "split.go")
+	if err := copyFile(source, filepath.Join("testdata", "split", "split.go")); err != nil {
+		t.Fatalf("copying file to temporary directory: %s", err)
+	}
+
+	err := run(exePath, "-type", "Err,Code", "-split", "-stack", source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := []string{source}
+	for _, name := range []string{"err_def.go", "code_def.go", "gorror_common.go"} {
+		file := filepath.Join(srcDir, name)
+		if _, err := os.Stat(file); err != nil {
+			t.Fatalf("expected generated file: %s", err)
+		}
+		files = append(files, file)
+	}
+	if err := run("go", append([]string{"run"}, files...)...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFast(t *testing.T) {
+	tmpdir, exePath := buildGorror(t)
+	var files []string
+	for _, name := range []string{"fast.go", "fast_test.go"} {
+		file := filepath.Join(tmpdir, name)
+		if err := copyFile(file, filepath.Join("testdata", "fast", name)); err != nil {
+			t.Fatalf("copying file to temporary directory: %s", err)
+		}
+		files = append(files, file)
+	}
+
+	// Generate the same error with and without -fast, sharing the common declarations.
+	source := files[0]
+	if err := run(exePath, "-type", "Err", "-split", source); err != nil {
+		t.Fatal(err)
+	}
+	if err := run(exePath, "-type", "FastErr", "-split", "-fast", source); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"err_def.go", "fasterr_def.go", "gorror_common.go"} {
+		files = append(files, filepath.Join(tmpdir, name))
+	}
+	args := append([]string{"test", "-bench", ".", "-benchtime", "100x"}, files...)
+	if err := run("go", args...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	tmpdir, exePath := buildGorror(t)
+	srcDir := filepath.Join(tmpdir, "src")
+	if err := os.Mkdir(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	source := filepath.Join(srcDir, "watch.go")
+	src := "package main\n\ntype Err string\n\nconst ErrOpen = Err(\"failed to open\")\n"
+	if err := os.WriteFile(source, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(srcDir, "err_def.go")
+
+	cmd := exec.Command(exePath, "-type", "Err", "-watch", source)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+
+	// waitFor polls the output until it contains the given text.
+	waitFor := func(text string) {
+		t.Helper()
+		for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); {
+			if out, err := os.ReadFile(output); err == nil && strings.Contains(string(out), text) {
+				return
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		t.Fatalf("output does not contain %q", text)
+	}
+	waitFor("type errOpen struct")
+	src += "\nconst ErrRead = Err(\"failed to read\")\n"
+	if err := os.WriteFile(source, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor("type errRead struct")
+
+	// A failed regeneration does not stop watching, nor does the removal of the source.
+	if err := os.WriteFile(source, []byte(src+"\nconst ErrBad = Err(\"exit:256 failed\")\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * watchDebounce)
+	if err := os.Remove(source); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * watchDebounce)
+	src += "\nconst ErrWrite = Err(\"failed to write\")\n"
+	if err := os.WriteFile(source, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor("type errWrite struct")
+}
+
+func TestPackageFlag(t *testing.T) {
+	tmpdir, exePath := buildGorror(t)
+	source := filepath.Join(tmpdir, "usage.go")
+	if err := copyFile(source, filepath.Join("testdata", "usage.go")); err != nil {
+		t.Fatalf("copying file to temporary directory: %s", err)
+	}
+	outDir := filepath.Join(tmpdir, "empty")
+	if err := os.Mkdir(outDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(outDir, "err_def.go")
+
+	if err := run(exePath, "-type", "Err", "-package", "other", "-output", output, source); err != nil {
+		t.Fatal(err)
+	}
+	src, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), "\npackage other\n") {
+		t.Errorf("generated file does not declare package other:\n%s", src)
+	}
+}
+
+func TestGeneratePackages(t *testing.T) {
+	defer resetFlags()
+	writeTree(t, map[string]string{
+		"go.mod":   "module example.com/multi\n\ngo 1.16\n",
+		"a/a.go":   "package a\ntype Err string\nconst ErrA = Err(\"failed a\")\n",
+		"b/b.go":   "package b\ntype Err string\nconst ErrB = Err(\"failed {{n int %d}}\")\n",
+		"c/c.go":   "package c\ntype Err string\nconst ErrC = Err(\"nowrap:failed c\")\n",
+		"dup/d.go": "package dup\ntype Err string\nconst (\n\tErrD = Err(\"d\")\n\terrD = Err(\"d\")\n)\n",
+		"bad/b.go": "package bad\ntype Err string\nconst ErrBad = Err(\"exit:256 failed\")\n",
+	})
+
+	resetFlags()
+	if err := flag.Set("type", "Err"); err != nil {
+		t.Fatal(err)
+	}
+	err := generatePackages(Generator{quiet: true}, []string{"./a", "./bad", "./b", "./dup", "./c"})
+	if err == nil || !strings.Contains(err.Error(), "dup") || !strings.Contains(err.Error(), "ErrBad") {
+		t.Errorf("got %v, expected the errors of packages dup and bad", err)
+	}
+	// The failure of a package does not prevent the others from being generated.
+	for _, pkg := range []string{"a", "b", "c"} {
+		src, err := os.ReadFile(filepath.Join(pkg, "err_def.go"))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !strings.Contains(string(src), "\npackage "+pkg+"\n") {
+			t.Errorf("%s: generated file does not declare package %s:\n%s", pkg, pkg, src)
+		}
+	}
+	if err := run("go", "build", "./a", "./b", "./c"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestImportAlias(t *testing.T) {
+	defer resetFlags()
+	writeTree(t, map[string]string{
+		"go.mod":         "module example.com/app\n\ngo 1.16\n",
+		"mypkg/thing.go": "package mything\n\ntype Thing string\n",
+		"errs/errs.go": "package errs\n\nimport mp \"example.com/app/mypkg\"\n\n" +
+			"type Err string\n\nconst ErrThing = Err(\"bad thing {{v mp.Thing %s}}\")\n\nvar _ mp.Thing\n",
+	})
+
+	var out strings.Builder
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+	resetFlags()
+	if err := flag.CommandLine.Parse([]string{"-type", "Err", "-import", "mp=example.com/app/mypkg", "./errs"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := execute(flag.Args()); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "warning") {
+		t.Errorf("unexpected warning:\n%s", out.String())
+	}
+	src, err := os.ReadFile(filepath.Join("errs", "err_def.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), "\tmp \"example.com/app/mypkg\"\n") {
+		t.Errorf("generated file does not import the aliased package:\n%s", src)
+	}
+	if err := run("go", "build", "./errs"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	defer resetFlags()
+	writeTree(t, map[string]string{
+		"go.mod":         "module example.com/app\n\ngo 1.16\n",
+		"errs/open.go":   "package errs\n\ntype Err string\n\nconst ErrOpen = Err(\"failed to open {{file string %q}}\")\n",
+		"errs/read.go":   "package errs\n\nconst ErrRead = Err(\"nowrap:failed to read {{n int %d}} bytes\")\n",
+		"errs/other.go":  "package other\n",
+		"other/close.go": "package errs\n\nconst ErrClose = Err(\"failed to close\")\n",
+	})
+
+	for _, test := range []struct {
+		files []string
+		err   string
+	}{
+		{[]string{"errs/open.go", "errs/other.go"},
+			"files errs/open.go (package errs) and errs/other.go (package other) are in different packages, expected a single one"},
+		{[]string{"errs/open.go", "other/close.go"},
+			"files errs/open.go and other/close.go are in different directories, expected a single package"},
+	} {
+		if err := checkFiles(test.files); err == nil || err.Error() != test.err {
+			t.Errorf("%v: got error %v, expected %q", test.files, err, test.err)
+		}
+	}
+
+	resetFlags()
+	if err := flag.CommandLine.Parse([]string{"-type", "Err", "errs/open.go", "errs/read.go"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := execute(flag.Args()); err != nil {
+		t.Fatal(err)
+	}
+	src, err := os.ReadFile(filepath.Join("errs", "err_def.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"type errOpen struct", "type errRead struct"} {
+		if !strings.Contains(string(src), s) {
+			t.Errorf("generated file does not contain %q:\n%s", s, src)
+		}
+	}
+	// The generated file completes the package of the given files.
+	if err := run("go", "build", "errs/open.go", "errs/read.go", "errs/err_def.go"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSiblingType(t *testing.T) {
+	defer resetFlags()
+	writeTree(t, map[string]string{
+		"go.mod":        "module example.com/app\n\ngo 1.16\n",
+		"errs/types.go": "package errs\n\ntype Err string\n",
+		"errs/errs.go":  "package errs\n\nconst ErrOpen = Err(\"failed to open {{file string %q}}\")\n",
+	})
+
+	// The type is declared in a file of the package not given as argument.
+	resetFlags()
+	if err := flag.CommandLine.Parse([]string{"-type", "Err", "errs/errs.go"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := execute(flag.Args()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join("errs", "err_def.go")); err != nil {
+		t.Fatal(err)
+	}
+	if err := run("go", "build", "./errs"); err != nil {
+		t.Error(err)
+	}
+}
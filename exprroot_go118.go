@@ -0,0 +1,19 @@
+// (c) Copyright 2021, Gorror Authors.
+//
+// Licensed under the terms of the GNU GPL License version 3.
+
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import "go/ast"
+
+// indexListX returns the indexed expression of an index expression with multiple indices, as
+// used to instantiate generic types and functions (e.g. m[K, V]).
+func indexListX(node ast.Expr) (ast.Expr, bool) {
+	if n, ok := node.(*ast.IndexListExpr); ok {
+		return n.X, true
+	}
+	return nil, false
+}
@@ -0,0 +1,16 @@
+// (c) Copyright 2021, Gorror Authors.
+//
+// Licensed under the terms of the GNU GPL License version 3.
+
+//go:build !go1.18
+// +build !go1.18
+
+package main
+
+import "go/ast"
+
+// indexListX returns the indexed expression of an index expression with multiple indices, which
+// do not exist before Go 1.18.
+func indexListX(node ast.Expr) (ast.Expr, bool) {
+	return nil, false
+}
@@ -0,0 +1,34 @@
+// (c) Copyright 2021, Gorror Authors.
+//
+// Licensed under the terms of the GNU GPL License version 3.
+
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import "testing"
+
+func FuzzParseTemplate(f *testing.F) {
+	for _, seed := range []string{
+		"some error",
+		"wrap:some error",
+		"nowrap:failed for {{c.Field[0] MyStruct %s}} ({{code uint %d}})",
+		"multiwrap:client:{{op string %s}}: {{cause}} on {{file string %q}}",
+		"dynamic:msg {{op string %s}}",
+		"100%% done {{n int %d}}",
+		"{{}} {{a}} {{a string}} {{{a string %s}}",
+		"{{a[ string %s}} {{.a string %s}}",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, template string) {
+		parsed, err := parseTemplate(template)
+		if err != nil {
+			return
+		}
+		if err := parsed.checkVerbs(); err != nil {
+			t.Errorf("%q: %v", template, err)
+		}
+	})
+}
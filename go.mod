@@ -2,4 +2,7 @@ module github.com/acidghost/gorror
 
 go 1.16
 
-require golang.org/x/tools v0.1.0
+require (
+	github.com/fsnotify/fsnotify v1.4.9
+	golang.org/x/tools v0.1.0
+)
@@ -0,0 +1,32 @@
+// (c) Copyright 2021, Gorror Authors.
+//
+// Licensed under the terms of the GNU GPL License version 3.
+
+//go:build go1.18
+// +build go1.18
+
+package main
+
+// Test cases relying on syntax introduced by Go 1.18.
+func init() {
+	golden = append(golden,
+		Golden{"genericListAccessor", Generator{}, genericListAccessorIn, genericListAccessorOut},
+	)
+}
+
+const genericListAccessorIn = `type Err string
+const ErrLookup = Err("nowrap:lookup of {{c.Get[string,int]() Cache %v}} failed")`
+
+const genericListAccessorOut = `type errLookup struct {
+	c Cache
+}
+
+func newErrLookup(c Cache) *errLookup {
+	return &errLookup{c}
+}
+
+func (e *errLookup) Error() string {
+	return fmt.Sprintf("lookup of %v failed", e.c.Get[string, int]())
+}
+
+func (*errLookup) Is(e Err) bool { return e == ErrLookup }`
@@ -12,21 +12,109 @@ import (
 )
 
 var golden = []Golden{
-	{"simple", false, simpleIn, simpleOut},
-	{"simpleCompatIs", true, simpleIn, simpleErrIsOut},
-	{"oneField", false, oneFieldIn, oneFieldOut},
-	{"multiFields", false, multiFieldsIn, multiFieldsOut},
-	{"complexField", false, complexFieldIn, complexFieldOut},
-	{"mustWrap", false, mustWrapIn, mustWrapOut},
-	{"noWrap", false, noWrapIn, noWrapOut},
+	{"simple", Generator{}, simpleIn, simpleOut},
+	{"simpleCompatIs", Generator{compatIs: true}, simpleIn, simpleErrIsOut},
+	{"oneField", Generator{}, oneFieldIn, oneFieldOut},
+	{"multiFields", Generator{}, multiFieldsIn, multiFieldsOut},
+	{"complexField", Generator{}, complexFieldIn, complexFieldOut},
+	{"mustWrap", Generator{}, mustWrapIn, mustWrapOut},
+	{"noWrap", Generator{}, noWrapIn, noWrapOut},
+	{"stack", Generator{stack: true}, oneFieldIn, stackOut},
+	{"qualifiedField", Generator{}, qualifiedFieldIn, qualifiedFieldOut},
+	{"typedConst", Generator{}, typedConstIn, simpleOut},
+	{"intEnum", Generator{}, intEnumIn, intEnumOut},
+	{"commentTemplate", Generator{}, commentTemplateIn, commentTemplateOut},
+	{"codeMatches", Generator{codeMatches: true}, noWrapIn, codeMatchesOut},
+	{"clientError", Generator{}, clientErrorIn, clientErrorOut},
+	{"serverError", Generator{}, serverErrorIn, serverErrorOut},
+	{"dynamic", Generator{}, dynamicIn, dynamicOut},
+	{"registry", Generator{registry: true}, registryIn, registryOut},
+	{"registryPublic", Generator{registry: true, makePub: true, specSuffix: "Spec"}, registryPublicIn,
+		registryPublicOut},
+	{"suppressed", Generator{suppressed: true}, oneFieldIn, suppressedOut},
+	{"suppressedNoWrap", Generator{suppressed: true}, noWrapIn, suppressedNoWrapOut},
+	{"immutable", Generator{immutable: true}, mustWrapIn, immutableOut},
+	{"multiWrap", Generator{}, multiWrapIn, multiWrapOut},
+	{"inlineCause", Generator{}, inlineCauseIn, inlineCauseOut},
+	{"inlineCauseMustWrap", Generator{}, inlineCauseMustWrapIn, inlineCauseMustWrapOut},
+	{"wrappedAccessor", Generator{wrapped: true}, mustWrapIn, wrappedAccessorOut},
+	{"wrappedAccessorNoWrap", Generator{wrapped: true}, noWrapIn, noWrapOut},
+	{"fmtModes", Generator{fmtModes: true}, oneFieldIn, fmtModesOut},
+	{"fmtModesNoWrap", Generator{fmtModes: true}, noWrapIn, fmtModesNoWrapOut},
+	{"docComment", Generator{}, docCommentIn, docCommentOut},
+	{"reqID", Generator{reqIDKey: "requestIDKey"}, oneFieldIn, reqIDOut},
+	{"fast", Generator{fast: true}, fastIn, fastOut},
+	{"fastMustWrap", Generator{fast: true}, mustWrapIn, fastMustWrapOut},
+	{"causeFirstCtor", Generator{causeFirst: true}, causeFirstCtorIn, causeFirstCtorOut},
+	{"cliMethod", Generator{cliMethod: true, cliColor: "31"}, oneFieldIn, cliMethodOut},
+	{"cliMethodNoColor", Generator{cliMethod: true}, noWrapIn, cliMethodNoColorOut},
+	{"genericAccessor", Generator{}, genericAccessorIn, genericAccessorOut},
+	{"goString", Generator{goString: true}, causeFirstCtorIn, goStringOut},
+	{"ctorMap", Generator{ctorMap: true}, causeFirstCtorIn, ctorMapOut},
+	{"wrapVerbPlus", Generator{wrapVerb: "%+v"}, oneFieldIn, wrapVerbPlusOut},
+	{"wrapVerbString", Generator{wrapVerb: "%s"}, mustWrapIn, wrapVerbStringOut},
+	{"logOnce", Generator{logOnce: true}, oneFieldIn, logOnceOut},
+	{"isFunc", Generator{isFunc: true}, noWrapIn, isFuncOut},
+	{"sepComment", Generator{sepComment: true}, registryIn, sepCommentOut},
+	{"variadicField", Generator{}, variadicFieldIn, variadicFieldOut},
+	{"variadicFieldMustWrap", Generator{}, variadicFieldMustWrapIn, variadicFieldMustWrapOut},
+	{"exitCode", Generator{}, exitCodeIn, exitCodeOut},
+	{"metadata", Generator{metadata: true}, oneFieldIn, metadataOut},
+	{"cacheMsg", Generator{cacheMsg: true}, oneFieldIn, cacheMsgOut},
+	{"multiLine", Generator{}, multiLineIn, multiLineOut},
+	{"pattern", Generator{}, patternIn, patternOut},
+	{"asHelpers", Generator{asHelpers: true}, oneFieldIn, asHelpersOut},
+	{"asHelpersPub", Generator{asHelpers: true, makePub: true}, oneFieldIn, asHelpersPubOut},
+	{"deprecated", Generator{}, deprecatedIn, deprecatedOut},
+	{"methodAccessor", Generator{}, methodAccessorIn, methodAccessorOut},
+	{"text", Generator{text: true}, oneFieldIn, textOut},
+	{"matchFields", Generator{compatIs: true, matchFields: true}, matchFieldsIn, matchFieldsOut},
+	{"returnInterface", Generator{retIface: true}, returnInterfaceIn, returnInterfaceOut},
+	{"verbFlags", Generator{}, verbFlagsIn, verbFlagsOut},
+	{"codeEnum", Generator{codeEnum: true, codeBase: 1000}, codeEnumIn, codeEnumOut},
+	{"repeatedRoots", Generator{}, repeatedRootsIn, repeatedRootsOut},
+	{"severity", Generator{}, severityIn, severityOut},
+	{"net", Generator{}, netIn, netOut},
+	{"short", Generator{short: true}, shortIn, shortOut},
+	{"opt", Generator{}, optIn, optOut},
+	{"noIs", Generator{noIs: true}, noIsIn, noIsOut},
+	{"stringer", Generator{stringer: true}, stringerIn, stringerOut},
+	{"wrapParamCollision", Generator{}, wrapParamCollisionIn, wrapParamCollisionOut},
+	{"wrapParam", Generator{wrapParam: "reason"}, wrapParamIn, wrapParamOut},
+	{"iface", Generator{iface: "DomainError", domain: "storage"}, ifaceIn, ifaceOut},
+	{"unicodeField", Generator{}, unicodeFieldIn, unicodeFieldOut},
+	{"causer", Generator{causer: true}, causerIn, causerOut},
+	{"sentinels", Generator{sentinels: true}, sentinelsIn, sentinelsOut},
+	{"percent", Generator{}, percentIn, percentOut},
+	{"equal", Generator{equal: true}, equalIn, equalOut},
+	{"causeField", Generator{}, causeFieldIn, causeFieldOut},
+	{"options", Generator{options: true}, optionsIn, optionsOut},
+	{"renameReserved", Generator{renameRes: true}, renameReservedIn, renameReservedOut},
+	{"named", Generator{named: true}, namedIn, namedOut},
+	{"namedInline", Generator{named: true}, namedInlineIn, namedOut},
+	{"wrapCtor", Generator{wrapCtor: true, wrapCtorSfx: "Wrap"}, wrapCtorIn, wrapCtorOut},
+	{"structPrefix", Generator{structPfx: "gen", equal: true}, structPrefixIn, structPrefixOut},
+	{"structPrefixPub", Generator{structPfx: "gen", equal: true, makePub: true}, structPrefixIn, structPrefixPubOut},
+	{"emptyMessage", Generator{}, emptyMessageIn, emptyMessageOut},
+	{"context", Generator{context: true}, contextIn, contextOut},
+	{"emptyMessageFmtModes", Generator{fmtModes: true}, emptyMessageIn, emptyMessageFmtModesOut},
+	{"emptyMessageCLI", Generator{cliMethod: true, cliColor: "31"}, emptyMessageIn, emptyMessageCLIOut},
+	{"patternUnicode", Generator{}, patternUnicodeIn, patternUnicodeOut},
+	{"patternOpt", Generator{}, patternOptIn, patternOptOut},
+	{"patternOptions", Generator{options: true}, patternOptIn, patternOptionsOut},
+	{"reqIDCollision", Generator{reqIDKey: "requestIDKey"}, reqIDCollisionIn, reqIDCollisionOut},
+	{"cacheMsgCollision", Generator{cacheMsg: true}, cacheMsgCollisionIn, cacheMsgCollisionOut},
+	{"contextFmtModes", Generator{context: true, fmtModes: true}, contextRenderIn, contextFmtModesOut},
+	{"contextCLI", Generator{context: true, cliMethod: true, goString: true}, contextRenderIn, contextCLIOut},
+	{"commentTemplateIgnored", Generator{}, commentTemplateIgnoredIn, simpleOut},
 }
 
 // Golden represents a test case.
 type Golden struct {
-	name     string // name of the test case
-	compatIs bool   // enables compatibility with errors.Is
-	input    string // given input
-	output   string // expected output
+	name   string    // name of the test case
+	gen    Generator // generator options (typeName is taken from the input)
+	input  string    // given input
+	output string    // expected output
 }
 
 const simpleIn = `type Err string
@@ -194,6 +282,3114 @@ func (e *errSome) Error() string {
 
 func (*errSome) Is(e Err) bool { return e == ErrSome }`
 
+const stackOut = `type errOpen struct {
+	_errWrap
+	filename string
+	stack    []uintptr
+}
+
+func newErrOpen(filename string) *errOpen {
+	return &errOpen{_errWrap{nil}, filename, _errCallers()}
+}
+
+func (e *errOpen) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to open %q", e.filename)
+	}
+	return fmt.Sprintf("failed to open %q: %v", e.filename, e.cause)
+}
+
+func (e *errOpen) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (e *errOpen) StackTrace() []runtime.Frame {
+	return _errFrames(e.stack)
+}
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }`
+
+const qualifiedFieldIn = `type Err string
+const ErrTimeout = Err("timed out after {{d time.Duration %s}}")`
+
+const qualifiedFieldOut = `type errTimeout struct {
+	_errWrap
+	d time.Duration
+}
+
+func newErrTimeout(d time.Duration) *errTimeout {
+	return &errTimeout{_errWrap{nil}, d}
+}
+
+func (e *errTimeout) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("timed out after %s", e.d)
+	}
+	return fmt.Sprintf("timed out after %s: %v", e.d, e.cause)
+}
+
+func (e *errTimeout) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errTimeout) Is(e Err) bool { return e == ErrTimeout }`
+
+const typedConstIn = `type Err string
+const (
+	ErrOpen Err = "failed to open file"
+	codeA       = iota
+	codeB
+)`
+
+const intEnumIn = `type Code int
+const (
+	CodeOK Code = iota
+	ErrOpen //gorror:"failed to open {{file string %q}}"
+	// ErrRead is a non-wrapping error.
+	//gorror:"nowrap:failed to read"
+	ErrRead
+)`
+
+const intEnumOut = `type errOpen struct {
+	_errWrap
+	file string
+}
+
+func newErrOpen(file string) *errOpen {
+	return &errOpen{_errWrap{nil}, file}
+}
+
+func (e *errOpen) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to open %q", e.file)
+	}
+	return fmt.Sprintf("failed to open %q: %v", e.file, e.cause)
+}
+
+func (e *errOpen) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errOpen) Is(e Code) bool { return e == ErrOpen }
+
+// ErrRead is a non-wrapping error.
+type errRead struct {
+}
+
+// ErrRead is a non-wrapping error.
+func newErrRead() *errRead {
+	return &errRead{}
+}
+
+func (e *errRead) Error() string {
+	return fmt.Sprintf("failed to read")
+}
+
+func (*errRead) Is(e Code) bool { return e == ErrRead }`
+
+const commentTemplateIn = `type Err int
+// ErrOpen is returned when opening fails.
+//gorror: failed to open {{filename string %q}}
+const ErrOpen = Err(iota)`
+
+const commentTemplateOut = `// ErrOpen is returned when opening fails.
+type errOpen struct {
+	_errWrap
+	filename string
+}
+
+// ErrOpen is returned when opening fails.
+func newErrOpen(filename string) *errOpen {
+	return &errOpen{_errWrap{nil}, filename}
+}
+
+func (e *errOpen) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to open %q", e.filename)
+	}
+	return fmt.Sprintf("failed to open %q: %v", e.filename, e.cause)
+}
+
+func (e *errOpen) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }`
+
+const docCommentIn = `type Err string
+const (
+	ErrClose = Err("nowrap:failed to close file")
+	// ErrOpen is returned when the file cannot be opened.
+	//
+	// The cause is the error returned by os.Open.
+	ErrOpen = Err("nowrap:failed to open file")
+)`
+
+const docCommentOut = `type errClose struct {
+}
+
+func newErrClose() *errClose {
+	return &errClose{}
+}
+
+func (e *errClose) Error() string {
+	return fmt.Sprintf("failed to close file")
+}
+
+func (*errClose) Is(e Err) bool { return e == ErrClose }
+
+// ErrOpen is returned when the file cannot be opened.
+//
+// The cause is the error returned by os.Open.
+type errOpen struct {
+}
+
+// ErrOpen is returned when the file cannot be opened.
+//
+// The cause is the error returned by os.Open.
+func newErrOpen() *errOpen {
+	return &errOpen{}
+}
+
+func (e *errOpen) Error() string {
+	return fmt.Sprintf("failed to open file")
+}
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }`
+
+const commentTemplateIgnoredIn = `type Err string
+//gorror: failed to open {{filename string %q}}
+const ErrOpen = Err("failed to open file")`
+
+const codeMatchesOut = `type errSome struct {
+}
+
+func newErrSome() *errSome {
+	return &errSome{}
+}
+
+func (e *errSome) Error() string {
+	return fmt.Sprintf("some error")
+}
+
+func (*errSome) Is(e Err) bool { return e == ErrSome }
+
+func (*errSome) CodeMatches(code string) bool { return code == "ErrSome" }`
+
+const clientErrorIn = `type Err string
+const ErrInput = Err("client:nowrap:invalid {{field string %q}}")`
+
+const clientErrorOut = `type errInput struct {
+	field string
+}
+
+func newErrInput(field string) *errInput {
+	return &errInput{field}
+}
+
+func (e *errInput) Error() string {
+	return fmt.Sprintf("invalid %q", e.field)
+}
+
+func (*errInput) Is(e Err) bool { return e == ErrInput }
+
+func (*errInput) IsClientError() bool { return true }
+
+func (*errInput) IsServerError() bool { return false }`
+
+const serverErrorIn = `type Err string
+const ErrDB = Err("wrap:server:database failure")`
+
+const serverErrorOut = `type errDB struct {
+	_errWrap
+}
+
+func newErrDB(err error) *errDB {
+	return &errDB{_errWrap{err}}
+}
+
+func (e *errDB) Error() string {
+	return fmt.Sprintf("database failure: %v", e.cause)
+}
+
+func (e *errDB) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errDB) Is(e Err) bool { return e == ErrDB }
+
+func (*errDB) IsClientError() bool { return false }
+
+func (*errDB) IsServerError() bool { return true }`
+
+const dynamicIn = `type Err string
+const ErrOpen = Err("dynamic:openMessage failed to open {{file string %q}}")`
+
+const dynamicOut = `type errOpen struct {
+	_errWrap
+	file string
+}
+
+func newErrOpen(file string) *errOpen {
+	return &errOpen{_errWrap{nil}, file}
+}
+
+func (e *errOpen) Error() string {
+	return openMessage(e)
+}
+
+func (e *errOpen) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }`
+
+const registryIn = `type Err string
+const (
+	ErrOpen = Err("nowrap:failed to open")
+	ErrRead = Err("nowrap:failed to read")
+)`
+
+const registryOut = `type errOpen struct {
+}
+
+func newErrOpen() *errOpen {
+	return &errOpen{}
+}
+
+func (e *errOpen) Error() string {
+	return fmt.Sprintf("failed to open")
+}
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }
+
+type errRead struct {
+}
+
+func newErrRead() *errRead {
+	return &errRead{}
+}
+
+func (e *errRead) Error() string {
+	return fmt.Sprintf("failed to read")
+}
+
+func (*errRead) Is(e Err) bool { return e == ErrRead }
+
+var allErr = []Err{ErrOpen, ErrRead}`
+
+const registryPublicIn = `type Err string
+const (
+	ErrOpenSpec = Err("nowrap:failed to open")
+	ErrReadSpec = Err("nowrap:failed to read")
+)`
+
+const registryPublicOut = `type ErrOpen struct {
+}
+
+func NewErrOpen() *ErrOpen {
+	return &ErrOpen{}
+}
+
+func (e *ErrOpen) Error() string {
+	return fmt.Sprintf("failed to open")
+}
+
+func (*ErrOpen) Is(e Err) bool { return e == ErrOpenSpec }
+
+type ErrRead struct {
+}
+
+func NewErrRead() *ErrRead {
+	return &ErrRead{}
+}
+
+func (e *ErrRead) Error() string {
+	return fmt.Sprintf("failed to read")
+}
+
+func (*ErrRead) Is(e Err) bool { return e == ErrReadSpec }
+
+var AllErr = []Err{ErrOpenSpec, ErrReadSpec}`
+
+const suppressedOut = `type errOpen struct {
+	_errWrap
+	filename   string
+	suppressed []error
+}
+
+func newErrOpen(filename string) *errOpen {
+	return &errOpen{_errWrap{nil}, filename, nil}
+}
+
+func (e *errOpen) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to open %q", e.filename)
+	}
+	return fmt.Sprintf("failed to open %q: %v", e.filename, e.cause)
+}
+
+func (e *errOpen) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (e *errOpen) AddSuppressed(err error) {
+	e.suppressed = append(e.suppressed, err)
+}
+
+func (e *errOpen) Unwrap() []error {
+	if e.cause == nil {
+		return e.suppressed
+	}
+	return append([]error{e.cause}, e.suppressed...)
+}
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }`
+
+const suppressedNoWrapOut = `type errSome struct {
+	suppressed []error
+}
+
+func newErrSome() *errSome {
+	return &errSome{nil}
+}
+
+func (e *errSome) Error() string {
+	return fmt.Sprintf("some error")
+}
+
+func (e *errSome) AddSuppressed(err error) {
+	e.suppressed = append(e.suppressed, err)
+}
+
+func (e *errSome) Unwrap() []error { return e.suppressed }
+
+func (*errSome) Is(e Err) bool { return e == ErrSome }`
+
+const immutableOut = `type errSome struct {
+	_errWrap
+}
+
+func newErrSome(err error) *errSome {
+	return &errSome{_errWrap{err}}
+}
+
+func (e *errSome) Error() string {
+	return fmt.Sprintf("some error: %v", e.cause)
+}
+
+func (e *errSome) Wrap(cause error) error {
+	c := *e
+	c.cause = cause
+	return &c
+}
+
+func (*errSome) Is(e Err) bool { return e == ErrSome }`
+
+const multiWrapIn = `type Err string
+const ErrClose = Err("multiwrap:failed to close {{file string %q}}")`
+
+const multiWrapOut = `type errClose struct {
+	causes []error
+	file   string
+}
+
+func newErrClose(file string, causes ...error) *errClose {
+	return &errClose{causes, file}
+}
+
+func (e *errClose) Error() string {
+	if len(e.causes) == 0 {
+		return fmt.Sprintf("failed to close %q", e.file)
+	}
+	return fmt.Sprintf("failed to close %q: %s", e.file, _errJoin(e.causes))
+}
+
+func (e *errClose) Unwrap() []error { return e.causes }
+
+func (*errClose) Is(e Err) bool { return e == ErrClose }`
+
+const inlineCauseIn = `type Err string
+const ErrFileOp = Err("while {{op string %s}} (cause: {{cause}}) on {{file string %q}}")`
+
+const inlineCauseOut = `type errFileOp struct {
+	_errWrap
+	op   string
+	file string
+}
+
+func newErrFileOp(op string, file string) *errFileOp {
+	return &errFileOp{_errWrap{nil}, op, file}
+}
+
+func (e *errFileOp) Error() string {
+	return fmt.Sprintf("while %s (cause: %v) on %q", e.op, e.cause, e.file)
+}
+
+func (e *errFileOp) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errFileOp) Is(e Err) bool { return e == ErrFileOp }`
+
+const inlineCauseMustWrapIn = `type Err string
+const ErrSome = Err("wrap:{{cause}} while opening {{file string %q}}")`
+
+const inlineCauseMustWrapOut = `type errSome struct {
+	_errWrap
+	file string
+}
+
+func newErrSome(file string, err error) *errSome {
+	return &errSome{_errWrap{err}, file}
+}
+
+func (e *errSome) Error() string {
+	return fmt.Sprintf("%v while opening %q", e.cause, e.file)
+}
+
+func (e *errSome) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errSome) Is(e Err) bool { return e == ErrSome }`
+
+const wrappedAccessorOut = `type errSome struct {
+	_errWrap
+}
+
+func newErrSome(err error) *errSome {
+	return &errSome{_errWrap{err}}
+}
+
+func (e *errSome) Error() string {
+	return fmt.Sprintf("some error: %v", e.cause)
+}
+
+func (e *errSome) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (e *errSome) Wrapped() error { return e.cause }
+
+func (*errSome) Is(e Err) bool { return e == ErrSome }`
+
+const fmtModesOut = `type errOpen struct {
+	_errWrap
+	filename string
+}
+
+func newErrOpen(filename string) *errOpen {
+	return &errOpen{_errWrap{nil}, filename}
+}
+
+func (e *errOpen) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to open %q", e.filename)
+	}
+	return fmt.Sprintf("failed to open %q: %v", e.filename, e.cause)
+}
+
+func (e *errOpen) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (e *errOpen) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 's':
+		fmt.Fprintf(f, "failed to open %q", e.filename)
+	case 'v':
+		if f.Flag('+') && e.cause != nil {
+			fmt.Fprintf(f, "failed to open %q: %+v", e.filename, e.cause)
+			return
+		}
+		fmt.Fprint(f, e.Error())
+	default:
+		fmt.Fprintf(f, "%"+string(verb), e.Error())
+	}
+}
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }`
+
+const fmtModesNoWrapOut = `type errSome struct {
+}
+
+func newErrSome() *errSome {
+	return &errSome{}
+}
+
+func (e *errSome) Error() string {
+	return fmt.Sprintf("some error")
+}
+
+func (e *errSome) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 's', 'v':
+		fmt.Fprint(f, e.Error())
+	default:
+		fmt.Fprintf(f, "%"+string(verb), e.Error())
+	}
+}
+
+func (*errSome) Is(e Err) bool { return e == ErrSome }`
+
+const reqIDOut = `type errOpen struct {
+	_errWrap
+	filename  string
+	requestID string
+}
+
+func newErrOpen(filename string) *errOpen {
+	return &errOpen{_errWrap{nil}, filename, ""}
+}
+
+func newErrOpenCtx(_ctx context.Context, filename string) *errOpen {
+	_e := &errOpen{_errWrap{nil}, filename, ""}
+	_e.requestID, _ = _ctx.Value(requestIDKey).(string)
+	return _e
+}
+
+func (e *errOpen) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to open %q", e.filename)
+	}
+	return fmt.Sprintf("failed to open %q: %v", e.filename, e.cause)
+}
+
+func (e *errOpen) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (e *errOpen) RequestID() string { return e.requestID }
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }`
+
+const fastIn = `type Err string
+const ErrFileOp = Err("failed to {{op string %s}} {{file string %q}} (code {{code int %d}}, mode {{mode uint32 %o}})")`
+
+const fastOut = `type errFileOp struct {
+	_errWrap
+	op   string
+	file string
+	code int
+	mode uint32
+}
+
+func newErrFileOp(op string, file string, code int, mode uint32) *errFileOp {
+	return &errFileOp{_errWrap{nil}, op, file, code, mode}
+}
+
+func (e *errFileOp) Error() string {
+	var b strings.Builder
+	b.WriteString("failed to ")
+	b.WriteString(e.op)
+	b.WriteString(" ")
+	b.WriteString(strconv.Quote(e.file))
+	b.WriteString(" (code ")
+	b.WriteString(strconv.Itoa(e.code))
+	b.WriteString(", mode ")
+	fmt.Fprintf(&b, "%o", e.mode)
+	b.WriteString(")")
+	if e.cause != nil {
+		b.WriteString(": ")
+		b.WriteString(e.cause.Error())
+	}
+	return b.String()
+}
+
+func (e *errFileOp) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errFileOp) Is(e Err) bool { return e == ErrFileOp }`
+
+const fastMustWrapOut = `type errSome struct {
+	_errWrap
+}
+
+func newErrSome(err error) *errSome {
+	return &errSome{_errWrap{err}}
+}
+
+func (e *errSome) Error() string {
+	var b strings.Builder
+	b.WriteString("some error")
+	b.WriteString(": ")
+	if e.cause == nil {
+		b.WriteString("<nil>")
+	} else {
+		b.WriteString(e.cause.Error())
+	}
+	return b.String()
+}
+
+func (e *errSome) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errSome) Is(e Err) bool { return e == ErrSome }`
+
+const causeFirstCtorIn = `type Err string
+const ErrFileOp = Err("wrap:failed to {{op string %s}} {{file string %q}}")`
+
+const causeFirstCtorOut = `type errFileOp struct {
+	_errWrap
+	op   string
+	file string
+}
+
+func newErrFileOp(err error, op string, file string) *errFileOp {
+	return &errFileOp{_errWrap{err}, op, file}
+}
+
+func (e *errFileOp) Error() string {
+	return fmt.Sprintf("failed to %s %q: %v", e.op, e.file, e.cause)
+}
+
+func (e *errFileOp) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errFileOp) Is(e Err) bool { return e == ErrFileOp }`
+
+const cliMethodOut = `type errOpen struct {
+	_errWrap
+	filename string
+}
+
+func newErrOpen(filename string) *errOpen {
+	return &errOpen{_errWrap{nil}, filename}
+}
+
+func (e *errOpen) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to open %q", e.filename)
+	}
+	return fmt.Sprintf("failed to open %q: %v", e.filename, e.cause)
+}
+
+func (e *errOpen) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (e *errOpen) CLIString() string {
+	s := "\x1b[31m" + fmt.Sprintf("failed to open %q", e.filename) + "\x1b[0m"
+	if e.cause != nil {
+		s += ": " + "\x1b[2m" + e.cause.Error() + "\x1b[0m"
+	}
+	return s
+}
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }`
+
+const cliMethodNoColorOut = `type errSome struct {
+}
+
+func newErrSome() *errSome {
+	return &errSome{}
+}
+
+func (e *errSome) Error() string {
+	return fmt.Sprintf("some error")
+}
+
+func (e *errSome) CLIString() string {
+	return e.Error()
+}
+
+func (*errSome) Is(e Err) bool { return e == ErrSome }`
+
+const genericAccessorIn = `type Err string
+const ErrLookup = Err("nowrap:lookup of {{c.Get[int]() Cache %v}} failed")`
+
+const genericAccessorOut = `type errLookup struct {
+	c Cache
+}
+
+func newErrLookup(c Cache) *errLookup {
+	return &errLookup{c}
+}
+
+func (e *errLookup) Error() string {
+	return fmt.Sprintf("lookup of %v failed", e.c.Get[int]())
+}
+
+func (*errLookup) Is(e Err) bool { return e == ErrLookup }`
+
+const goStringOut = `type errFileOp struct {
+	_errWrap
+	op   string
+	file string
+}
+
+func newErrFileOp(op string, file string, err error) *errFileOp {
+	return &errFileOp{_errWrap{err}, op, file}
+}
+
+func (e *errFileOp) Error() string {
+	return fmt.Sprintf("failed to %s %q: %v", e.op, e.file, e.cause)
+}
+
+func (e *errFileOp) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (e *errFileOp) GoString() string {
+	return fmt.Sprintf("errFileOp{op: %#v, file: %#v, cause: %#v}", e.op, e.file, e.cause)
+}
+
+func (*errFileOp) Is(e Err) bool { return e == ErrFileOp }`
+
+const ctorMapOut = `type errFileOp struct {
+	_errWrap
+	op   string
+	file string
+}
+
+func newErrFileOp(op string, file string, err error) *errFileOp {
+	return &errFileOp{_errWrap{err}, op, file}
+}
+
+func (e *errFileOp) Error() string {
+	return fmt.Sprintf("failed to %s %q: %v", e.op, e.file, e.cause)
+}
+
+func (e *errFileOp) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errFileOp) Is(e Err) bool { return e == ErrFileOp }
+
+var errCtors = map[Err]func(args ...interface{}) (error, error){
+	ErrFileOp: func(args ...interface{}) (error, error) {
+		if len(args) != 3 {
+			return nil, fmt.Errorf("ErrFileOp: got %d arguments, expected 3", len(args))
+		}
+		a0, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("ErrFileOp: argument 0 has type %T, expected string", args[0])
+		}
+		a1, ok := args[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("ErrFileOp: argument 1 has type %T, expected string", args[1])
+		}
+		a2, ok := args[2].(error)
+		if !ok && args[2] != nil {
+			return nil, fmt.Errorf("ErrFileOp: argument 2 has type %T, expected error", args[2])
+		}
+		return newErrFileOp(a0, a1, a2), nil
+	},
+}`
+
+const wrapVerbPlusOut = `type errOpen struct {
+	_errWrap
+	filename string
+}
+
+func newErrOpen(filename string) *errOpen {
+	return &errOpen{_errWrap{nil}, filename}
+}
+
+func (e *errOpen) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to open %q", e.filename)
+	}
+	return fmt.Sprintf("failed to open %q: %+v", e.filename, e.cause)
+}
+
+func (e *errOpen) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }`
+
+const wrapVerbStringOut = `type errSome struct {
+	_errWrap
+}
+
+func newErrSome(err error) *errSome {
+	return &errSome{_errWrap{err}}
+}
+
+func (e *errSome) Error() string {
+	return fmt.Sprintf("some error: %s", e.cause)
+}
+
+func (e *errSome) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errSome) Is(e Err) bool { return e == ErrSome }`
+
+const logOnceOut = `type errOpen struct {
+	_errWrap
+	filename string
+	logged   *atomic.Bool
+}
+
+func newErrOpen(filename string) *errOpen {
+	return &errOpen{_errWrap{nil}, filename, new(atomic.Bool)}
+}
+
+func (e *errOpen) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to open %q", e.filename)
+	}
+	return fmt.Sprintf("failed to open %q: %v", e.filename, e.cause)
+}
+
+func (e *errOpen) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (e *errOpen) MarkLogged() bool { return !e.logged.Swap(true) }
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }`
+
+const isFuncOut = `type errSome struct {
+}
+
+func newErrSome() *errSome {
+	return &errSome{}
+}
+
+func (e *errSome) Error() string {
+	return fmt.Sprintf("some error")
+}
+
+func (*errSome) Is(e Err) bool { return e == ErrSome }
+
+func isErrSome(err error) bool {
+	var e *errSome
+	return errors.As(err, &e)
+}`
+
+const sepCommentOut = `// ---- ErrOpen ----
+
+type errOpen struct {
+}
+
+func newErrOpen() *errOpen {
+	return &errOpen{}
+}
+
+func (e *errOpen) Error() string {
+	return fmt.Sprintf("failed to open")
+}
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }
+
+// ---- ErrRead ----
+
+type errRead struct {
+}
+
+func newErrRead() *errRead {
+	return &errRead{}
+}
+
+func (e *errRead) Error() string {
+	return fmt.Sprintf("failed to read")
+}
+
+func (*errRead) Is(e Err) bool { return e == ErrRead }`
+
+const variadicFieldIn = `type Err string
+const ErrMissing = Err("nowrap:missing {{files ...string %v}}")`
+
+const variadicFieldOut = `type errMissing struct {
+	files []string
+}
+
+func newErrMissing(files ...string) *errMissing {
+	return &errMissing{files}
+}
+
+func (e *errMissing) Error() string {
+	return fmt.Sprintf("missing %v", e.files)
+}
+
+func (*errMissing) Is(e Err) bool { return e == ErrMissing }`
+
+const variadicFieldMustWrapIn = `type Err string
+const ErrCopy = Err("wrap:failed to copy to {{dir string %q}}: {{files ...string %q}}")`
+
+const variadicFieldMustWrapOut = `type errCopy struct {
+	_errWrap
+	dir   string
+	files []string
+}
+
+func newErrCopy(dir string, err error, files ...string) *errCopy {
+	return &errCopy{_errWrap{err}, dir, files}
+}
+
+func (e *errCopy) Error() string {
+	return fmt.Sprintf("failed to copy to %q: %q: %v", e.dir, e.files, e.cause)
+}
+
+func (e *errCopy) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errCopy) Is(e Err) bool { return e == ErrCopy }`
+
+const exitCodeIn = `type Err string
+const ErrUsage = Err("exit:2 nowrap:invalid flag {{flag string %q}}")`
+
+const exitCodeOut = `type errUsage struct {
+	flag string
+}
+
+func newErrUsage(flag string) *errUsage {
+	return &errUsage{flag}
+}
+
+func (e *errUsage) Error() string {
+	return fmt.Sprintf("invalid flag %q", e.flag)
+}
+
+func (*errUsage) Is(e Err) bool { return e == ErrUsage }
+
+func (*errUsage) ExitCode() int { return 2 }`
+
+const metadataOut = `type errOpen struct {
+	_errWrap
+	filename string
+	meta     map[string]string
+}
+
+func newErrOpen(filename string) *errOpen {
+	return &errOpen{_errWrap{nil}, filename, nil}
+}
+
+func (e *errOpen) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to open %q", e.filename)
+	}
+	return fmt.Sprintf("failed to open %q: %v", e.filename, e.cause)
+}
+
+func (e *errOpen) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (e *errOpen) WithMeta(k, v string) *errOpen {
+	if e.meta == nil {
+		e.meta = make(map[string]string)
+	}
+	e.meta[k] = v
+	return e
+}
+
+func (e *errOpen) Meta() map[string]string { return e.meta }
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }`
+
+const cacheMsgOut = `type errOpen struct {
+	_errWrap
+	filename string
+	msg      string
+}
+
+func newErrOpen(filename string) *errOpen {
+	_e := &errOpen{_errWrap{nil}, filename, ""}
+	_e.msg = _e.formatMsg()
+	return _e
+}
+
+func (e *errOpen) Error() string { return e.msg }
+
+func (e *errOpen) formatMsg() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to open %q", e.filename)
+	}
+	return fmt.Sprintf("failed to open %q: %v", e.filename, e.cause)
+}
+
+func (e *errOpen) Wrap(cause error) error {
+	e.cause = cause
+	e.msg = e.formatMsg()
+	return e
+}
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }`
+
+const multiLineIn = `type Err string
+const ErrParse = Err(` + "`" + `nowrap:parse error at line {{line int %d}}:
+	"{{text string %s}}"` + "`" + `)`
+
+const multiLineOut = `type errParse struct {
+	line int
+	text string
+}
+
+func newErrParse(line int, text string) *errParse {
+	return &errParse{line, text}
+}
+
+func (e *errParse) Error() string {
+	return fmt.Sprintf("parse error at line %d:\n\t\"%s\"", e.line, e.text)
+}
+
+func (*errParse) Is(e Err) bool { return e == ErrParse }`
+
+const patternIn = `type Err string
+const ErrCode = Err("invalid code {{code string %s /^[A-Z]{3}$/}} for {{user int %d}}")`
+
+const patternOut = `type errCode struct {
+	_errWrap
+	code string
+	user int
+}
+
+var _errCodeCodeRE = regexp.MustCompile("^[A-Z]{3}$")
+
+func newErrCode(code string, user int) *errCode {
+	if !_errCodeCodeRE.MatchString(code) {
+		panic(fmt.Sprintf("newErrCode: code %q does not match %s", code, _errCodeCodeRE))
+	}
+	return &errCode{_errWrap{nil}, code, user}
+}
+
+func (e *errCode) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("invalid code %s for %d", e.code, e.user)
+	}
+	return fmt.Sprintf("invalid code %s for %d: %v", e.code, e.user, e.cause)
+}
+
+func (e *errCode) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errCode) Is(e Err) bool { return e == ErrCode }`
+
+const asHelpersOut = `type errOpen struct {
+	_errWrap
+	filename string
+}
+
+func newErrOpen(filename string) *errOpen {
+	return &errOpen{_errWrap{nil}, filename}
+}
+
+func (e *errOpen) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to open %q", e.filename)
+	}
+	return fmt.Sprintf("failed to open %q: %v", e.filename, e.cause)
+}
+
+func (e *errOpen) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }
+
+func asErrOpen(err error) (*errOpen, bool) {
+	var e *errOpen
+	ok := errors.As(err, &e)
+	return e, ok
+}`
+
+const asHelpersPubOut = `type ErrOpen struct {
+	_errWrap
+	filename string
+}
+
+func NewErrOpen(filename string) *ErrOpen {
+	return &ErrOpen{_errWrap{nil}, filename}
+}
+
+func (e *ErrOpen) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to open %q", e.filename)
+	}
+	return fmt.Sprintf("failed to open %q: %v", e.filename, e.cause)
+}
+
+func (e *ErrOpen) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*ErrOpen) Is(e Err) bool { return e == ErrOpen }
+
+func AsErrOpen(err error) (*ErrOpen, bool) {
+	var e *ErrOpen
+	ok := errors.As(err, &e)
+	return e, ok
+}`
+
+const deprecatedIn = `type Err string
+const (
+	ErrOpenFile = Err("nowrap:failed to open {{file string %q}}")
+	// ErrOpen is returned when a file cannot be opened.
+	ErrOpen = Err("deprecated:use ErrOpenFile nowrap:failed to open")
+	ErrRead = Err("deprecated:nowrap:failed to read")
+)`
+
+const deprecatedOut = `type errOpenFile struct {
+	file string
+}
+
+func newErrOpenFile(file string) *errOpenFile {
+	return &errOpenFile{file}
+}
+
+func (e *errOpenFile) Error() string {
+	return fmt.Sprintf("failed to open %q", e.file)
+}
+
+func (*errOpenFile) Is(e Err) bool { return e == ErrOpenFile }
+
+// ErrOpen is returned when a file cannot be opened.
+//
+// Deprecated: use ErrOpenFile instead.
+type errOpen struct {
+}
+
+// ErrOpen is returned when a file cannot be opened.
+//
+// Deprecated: use ErrOpenFile instead.
+func newErrOpen() *errOpen {
+	return &errOpen{}
+}
+
+func (e *errOpen) Error() string {
+	return fmt.Sprintf("failed to open")
+}
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }
+
+func (*errOpen) ReplacedBy() Err { return ErrOpenFile }
+
+// Deprecated: do not use.
+type errRead struct {
+}
+
+// Deprecated: do not use.
+func newErrRead() *errRead {
+	return &errRead{}
+}
+
+func (e *errRead) Error() string {
+	return fmt.Sprintf("failed to read")
+}
+
+func (*errRead) Is(e Err) bool { return e == ErrRead }`
+
+const methodAccessorIn = `type Err string
+const ErrConfig = Err("invalid config {{c.Name() Config %s}}")`
+
+const methodAccessorOut = `type errConfig struct {
+	_errWrap
+	c Config
+}
+
+func newErrConfig(c Config) *errConfig {
+	return &errConfig{_errWrap{nil}, c}
+}
+
+func (e *errConfig) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("invalid config %s", e.c.Name())
+	}
+	return fmt.Sprintf("invalid config %s: %v", e.c.Name(), e.cause)
+}
+
+func (e *errConfig) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errConfig) Is(e Err) bool { return e == ErrConfig }`
+
+const textOut = `type errOpen struct {
+	_errWrap
+	filename string
+}
+
+func newErrOpen(filename string) *errOpen {
+	return &errOpen{_errWrap{nil}, filename}
+}
+
+func (e *errOpen) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to open %q", e.filename)
+	}
+	return fmt.Sprintf("failed to open %q: %v", e.filename, e.cause)
+}
+
+func (e *errOpen) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (e *errOpen) MarshalText() ([]byte, error) { return []byte(e.Error()), nil }
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }`
+
+const matchFieldsIn = `type Err string
+const (
+	ErrOpen = Err("failed to open {{file string %q}} at {{line int %d}}")
+	ErrRead = Err("nowrap:failed to read")
+)`
+
+const matchFieldsOut = `type errOpen struct {
+	_errWrap
+	file string
+	line int
+}
+
+func newErrOpen(file string, line int) *errOpen {
+	return &errOpen{_errWrap{nil}, file, line}
+}
+
+func (e *errOpen) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to open %q at %d", e.file, e.line)
+	}
+	return fmt.Sprintf("failed to open %q at %d: %v", e.file, e.line, e.cause)
+}
+
+func (e *errOpen) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (e *errOpen) Is(target error) bool {
+	if t, ok := target.(*errOpen); ok {
+		return e.file == t.file && e.line == t.line
+	}
+	return target == ErrOpen
+}
+
+type errRead struct {
+}
+
+func newErrRead() *errRead {
+	return &errRead{}
+}
+
+func (e *errRead) Error() string {
+	return fmt.Sprintf("failed to read")
+}
+
+func (e *errRead) Is(target error) bool {
+	if _, ok := target.(*errRead); ok {
+		return true
+	}
+	return target == ErrRead
+}`
+
+const returnInterfaceIn = `type Err string
+const (
+	ErrOpen = Err("failed to open {{file string %q}}")
+	ErrRead = Err("nowrap:failed to read")
+	ErrWrite = Err("wrap:failed to write")
+)`
+
+const returnInterfaceOut = `type errOpen struct {
+	_errWrap
+	file string
+}
+
+func newErrOpen(file string) ErrWrapper {
+	return &errOpen{_errWrap{nil}, file}
+}
+
+func (e *errOpen) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to open %q", e.file)
+	}
+	return fmt.Sprintf("failed to open %q: %v", e.file, e.cause)
+}
+
+func (e *errOpen) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }
+
+type errRead struct {
+}
+
+func newErrRead() error {
+	return &errRead{}
+}
+
+func (e *errRead) Error() string {
+	return fmt.Sprintf("failed to read")
+}
+
+func (*errRead) Is(e Err) bool { return e == ErrRead }
+
+type errWrite struct {
+	_errWrap
+}
+
+func newErrWrite(err error) ErrWrapper {
+	return &errWrite{_errWrap{err}}
+}
+
+func (e *errWrite) Error() string {
+	return fmt.Sprintf("failed to write: %v", e.cause)
+}
+
+func (e *errWrite) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errWrite) Is(e Err) bool { return e == ErrWrite }`
+
+const verbFlagsIn = `type Err string
+const ErrSlow = Err("nowrap:{{op string %-10s}} took {{ms int % d}}ms")`
+
+const verbFlagsOut = `type errSlow struct {
+	op string
+	ms int
+}
+
+func newErrSlow(op string, ms int) *errSlow {
+	return &errSlow{op, ms}
+}
+
+func (e *errSlow) Error() string {
+	return fmt.Sprintf("%-10s took % dms", e.op, e.ms)
+}
+
+func (*errSlow) Is(e Err) bool { return e == ErrSlow }`
+
+const codeEnumIn = `type Err string
+const (
+	ErrRead = Err("nowrap:failed to read")
+	ErrOpen = Err("code:1000 nowrap:failed to open")
+	ErrClose = Err("nowrap:failed to close")
+)`
+
+const codeEnumOut = `type errRead struct {
+}
+
+func newErrRead() *errRead {
+	return &errRead{}
+}
+
+func (e *errRead) Error() string {
+	return fmt.Sprintf("failed to read")
+}
+
+func (*errRead) Is(e Err) bool { return e == ErrRead }
+
+func (*errRead) Code() ErrCode { return ErrReadCode }
+
+type errOpen struct {
+}
+
+func newErrOpen() *errOpen {
+	return &errOpen{}
+}
+
+func (e *errOpen) Error() string {
+	return fmt.Sprintf("failed to open")
+}
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }
+
+func (*errOpen) Code() ErrCode { return ErrOpenCode }
+
+type errClose struct {
+}
+
+func newErrClose() *errClose {
+	return &errClose{}
+}
+
+func (e *errClose) Error() string {
+	return fmt.Sprintf("failed to close")
+}
+
+func (*errClose) Is(e Err) bool { return e == ErrClose }
+
+func (*errClose) Code() ErrCode { return ErrCloseCode }
+
+type ErrCode int
+
+const (
+	ErrReadCode  ErrCode = 1002
+	ErrOpenCode  ErrCode = 1000
+	ErrCloseCode ErrCode = 1001
+)`
+
+const repeatedRootsIn = `type Err string
+const ErrMove = Err("nowrap:cannot move from {{a.X Point %d}} to {{b.Y Point %d}}, {{a.Z Point %d}} is blocked")`
+
+const repeatedRootsOut = `type errMove struct {
+	a Point
+	b Point
+}
+
+func newErrMove(a Point, b Point) *errMove {
+	return &errMove{a, b}
+}
+
+func (e *errMove) Error() string {
+	return fmt.Sprintf("cannot move from %d to %d, %d is blocked", e.a.X, e.b.Y, e.a.Z)
+}
+
+func (*errMove) Is(e Err) bool { return e == ErrMove }`
+
+const severityIn = `type Err string
+const ErrOpen = Err("sev:warn nowrap:failed to open {{file string %q}}")`
+
+const severityOut = `type errOpen struct {
+	file string
+}
+
+func newErrOpen(file string) *errOpen {
+	return &errOpen{file}
+}
+
+func (e *errOpen) Error() string {
+	return fmt.Sprintf("failed to open %q", e.file)
+}
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }
+
+func (*errOpen) Severity() string { return "warn" }`
+
+const netIn = `type Err string
+const ErrReset = Err("net:temp nowrap:connection reset")`
+
+const netOut = `type errReset struct {
+}
+
+func newErrReset() *errReset {
+	return &errReset{}
+}
+
+func (e *errReset) Error() string {
+	return fmt.Sprintf("connection reset")
+}
+
+func (*errReset) Is(e Err) bool { return e == ErrReset }
+
+func (*errReset) Temporary() bool { return true }
+
+func (*errReset) Timeout() bool { return false }`
+
+const shortIn = `type Err string
+const (
+	ErrOpen = Err("failed to open {{file string %q}}")
+	ErrRead = Err("nowrap:failed to read")
+)`
+
+const shortOut = `type errOpen struct {
+	_errWrap
+	file string
+}
+
+func newErrOpen(file string) *errOpen {
+	return &errOpen{_errWrap{nil}, file}
+}
+
+func (e *errOpen) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to open %q", e.file)
+	}
+	return fmt.Sprintf("failed to open %q: %v", e.file, _errShort(e.cause))
+}
+
+func (e *errOpen) errMsg() string {
+	return fmt.Sprintf("failed to open %q", e.file)
+}
+
+func (e *errOpen) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }
+
+type errRead struct {
+}
+
+func newErrRead() *errRead {
+	return &errRead{}
+}
+
+func (e *errRead) Error() string {
+	return fmt.Sprintf("failed to read")
+}
+
+func (e *errRead) errMsg() string {
+	return e.Error()
+}
+
+func (*errRead) Is(e Err) bool { return e == ErrRead }`
+
+const optIn = `type Err string
+const (
+	ErrDial  = Err("failed to dial {{addr string %s}} attempt {{retries opt int %d}}")
+	ErrQuery = Err("nowrap:query {{q string %q}} failed {{reason opt string %s}}")
+)`
+
+const optOut = `type errDial struct {
+	_errWrap
+	addr    string
+	retries int
+}
+
+func newErrDial(addr string, retries int) *errDial {
+	return &errDial{_errWrap{nil}, addr, retries}
+}
+
+func (e *errDial) Error() string {
+	if e.cause == nil {
+		return e.errMsg()
+	}
+	return fmt.Sprintf("%s: %v", e.errMsg(), e.cause)
+}
+
+func (e *errDial) errMsg() string {
+	var s string
+	s += fmt.Sprintf("failed to dial %s", e.addr)
+	if e.retries != 0 {
+		s += fmt.Sprintf(" attempt %d", e.retries)
+	}
+	return s
+}
+
+func (e *errDial) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errDial) Is(e Err) bool { return e == ErrDial }
+
+type errQuery struct {
+	q      string
+	reason string
+}
+
+func newErrQuery(q string, reason string) *errQuery {
+	return &errQuery{q, reason}
+}
+
+func (e *errQuery) Error() string {
+	return e.errMsg()
+}
+
+func (e *errQuery) errMsg() string {
+	var s string
+	s += fmt.Sprintf("query %q", e.q)
+	if e.reason != "" {
+		s += fmt.Sprintf(" failed %s", e.reason)
+	}
+	return s
+}
+
+func (*errQuery) Is(e Err) bool { return e == ErrQuery }`
+
+const noIsIn = `type Err string
+const (
+	ErrOpen = Err("failed to open {{file string %q}}")
+	ErrRead = Err("nowrap:failed to read")
+)`
+
+const noIsOut = `type errOpen struct {
+	_errWrap
+	file string
+}
+
+func newErrOpen(file string) *errOpen {
+	return &errOpen{_errWrap{nil}, file}
+}
+
+func (e *errOpen) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to open %q", e.file)
+	}
+	return fmt.Sprintf("failed to open %q: %v", e.file, e.cause)
+}
+
+func (e *errOpen) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+type errRead struct {
+}
+
+func newErrRead() *errRead {
+	return &errRead{}
+}
+
+func (e *errRead) Error() string {
+	return fmt.Sprintf("failed to read")
+}`
+
+const stringerIn = `type Err string
+const (
+	ErrOpen = Err("failed to open {{file string %q}}")
+	ErrRead = Err("nowrap:failed to read")
+)`
+
+const stringerOut = `type errOpen struct {
+	_errWrap
+	file string
+}
+
+func newErrOpen(file string) *errOpen {
+	return &errOpen{_errWrap{nil}, file}
+}
+
+func (e *errOpen) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to open %q", e.file)
+	}
+	return fmt.Sprintf("failed to open %q: %v", e.file, e.cause)
+}
+
+func (e *errOpen) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (e *errOpen) String() string { return e.Error() }
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }
+
+type errRead struct {
+}
+
+func newErrRead() *errRead {
+	return &errRead{}
+}
+
+func (e *errRead) Error() string {
+	return fmt.Sprintf("failed to read")
+}
+
+func (e *errRead) String() string { return e.Error() }
+
+func (*errRead) Is(e Err) bool { return e == ErrRead }`
+
+const wrapParamCollisionIn = `type Err string
+const ErrExec = Err("wrap:command failed with {{err string %s}}")`
+
+const wrapParamCollisionOut = `type errExec struct {
+	_errWrap
+	err string
+}
+
+func newErrExec(err string, cause error) *errExec {
+	return &errExec{_errWrap{cause}, err}
+}
+
+func (e *errExec) Error() string {
+	return fmt.Sprintf("command failed with %s: %v", e.err, e.cause)
+}
+
+func (e *errExec) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errExec) Is(e Err) bool { return e == ErrExec }`
+
+const wrapParamIn = `type Err string
+const (
+	ErrRun  = Err("wrap:failed to run {{cmd string %s}}")
+	ErrStop = Err("wrap:failed to stop: {{reason string %s}}")
+)`
+
+const wrapParamOut = `type errRun struct {
+	_errWrap
+	cmd string
+}
+
+func newErrRun(cmd string, reason error) *errRun {
+	return &errRun{_errWrap{reason}, cmd}
+}
+
+func (e *errRun) Error() string {
+	return fmt.Sprintf("failed to run %s: %v", e.cmd, e.cause)
+}
+
+func (e *errRun) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errRun) Is(e Err) bool { return e == ErrRun }
+
+type errStop struct {
+	_errWrap
+	reason string
+}
+
+func newErrStop(reason string, cause error) *errStop {
+	return &errStop{_errWrap{cause}, reason}
+}
+
+func (e *errStop) Error() string {
+	return fmt.Sprintf("failed to stop: %s: %v", e.reason, e.cause)
+}
+
+func (e *errStop) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errStop) Is(e Err) bool { return e == ErrStop }`
+
+const ifaceIn = `type Err string
+const ErrOpen = Err("nowrap:failed to open {{file string %q}}")
+type DomainError interface {
+	error
+	Domain() string
+}`
+
+const ifaceOut = `type errOpen struct {
+	file string
+}
+
+func newErrOpen(file string) *errOpen {
+	return &errOpen{file}
+}
+
+func (e *errOpen) Error() string {
+	return fmt.Sprintf("failed to open %q", e.file)
+}
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }
+
+func (*errOpen) Domain() string { return "storage" }
+
+var _ DomainError = (*errOpen)(nil)`
+
+const unicodeFieldIn = `type Err string
+const ErrMenu = Err("nowrap:no {{café string %s}} in {{menü.Größe Menü %d}}")
+type Menü struct{ Größe int }`
+
+const unicodeFieldOut = `type errMenu struct {
+	café string
+	menü Menü
+}
+
+func newErrMenu(café string, menü Menü) *errMenu {
+	return &errMenu{café, menü}
+}
+
+func (e *errMenu) Error() string {
+	return fmt.Sprintf("no %s in %d", e.café, e.menü.Größe)
+}
+
+func (*errMenu) Is(e Err) bool { return e == ErrMenu }`
+
+const causerIn = `type Err string
+const (
+	ErrOpen = Err("failed to open {{file string %q}}")
+	ErrRead = Err("nowrap:failed to read")
+)`
+
+const causerOut = `type errOpen struct {
+	_errWrap
+	file string
+}
+
+func newErrOpen(file string) *errOpen {
+	return &errOpen{_errWrap{nil}, file}
+}
+
+func (e *errOpen) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to open %q", e.file)
+	}
+	return fmt.Sprintf("failed to open %q: %v", e.file, e.cause)
+}
+
+func (e *errOpen) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (e *errOpen) Cause() error { return e.cause }
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }
+
+type errRead struct {
+}
+
+func newErrRead() *errRead {
+	return &errRead{}
+}
+
+func (e *errRead) Error() string {
+	return fmt.Sprintf("failed to read")
+}
+
+func (*errRead) Is(e Err) bool { return e == ErrRead }`
+
+const sentinelsIn = `type Err string
+const (
+	ErrEOF  = Err("nowrap:unexpected end of file")
+	ErrLine = Err("nowrap:invalid line {{line int %d}}")
+	ErrRead = Err("failed to read")
+)`
+
+const sentinelsOut = `type errEOF struct {
+}
+
+func newErrEOF() *errEOF {
+	return &errEOF{}
+}
+
+// errEOFInstance is a shared instance of ErrEOF, matched by errors.Is.
+var errEOFInstance = newErrEOF()
+
+func (e *errEOF) Error() string {
+	return fmt.Sprintf("unexpected end of file")
+}
+
+func (*errEOF) Is(e Err) bool { return e == ErrEOF }
+
+type errLine struct {
+	line int
+}
+
+func newErrLine(line int) *errLine {
+	return &errLine{line}
+}
+
+func (e *errLine) Error() string {
+	return fmt.Sprintf("invalid line %d", e.line)
+}
+
+func (*errLine) Is(e Err) bool { return e == ErrLine }
+
+type errRead struct {
+	_errWrap
+}
+
+func newErrRead() *errRead {
+	return &errRead{_errWrap{nil}}
+}
+
+func (e *errRead) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to read")
+	}
+	return fmt.Sprintf("failed to read: %v", e.cause)
+}
+
+func (e *errRead) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errRead) Is(e Err) bool { return e == ErrRead }`
+
+const percentIn = `type Err string
+const ErrFull = Err("nowrap:disk {{pct int %d}}% full")`
+
+const percentOut = `type errFull struct {
+	pct int
+}
+
+func newErrFull(pct int) *errFull {
+	return &errFull{pct}
+}
+
+func (e *errFull) Error() string {
+	return fmt.Sprintf("disk %d%% full", e.pct)
+}
+
+func (*errFull) Is(e Err) bool { return e == ErrFull }`
+
+const equalIn = `type Err string
+const (
+	ErrOpen  = Err("failed to open {{file string %q}} with mode {{mode int %o}}")
+	ErrParse = Err("nowrap:failed to parse {{file string %q}} at {{lines ...int %v}}")
+	ErrRead  = Err("nowrap:failed to read")
+	ErrSync  = Err("multiwrap:failed to sync")
+)`
+
+const equalOut = `type errOpen struct {
+	_errWrap
+	file string
+	mode int
+}
+
+func newErrOpen(file string, mode int) *errOpen {
+	return &errOpen{_errWrap{nil}, file, mode}
+}
+
+func (e *errOpen) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to open %q with mode %o", e.file, e.mode)
+	}
+	return fmt.Sprintf("failed to open %q with mode %o: %v", e.file, e.mode, e.cause)
+}
+
+func (e *errOpen) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (e *errOpen) Equal(other *errOpen) bool {
+	if e == nil || other == nil {
+		return e == other
+	}
+	return e.file == other.file &&
+		e.mode == other.mode &&
+		errors.Is(e.cause, other.cause)
+}
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }
+
+type errParse struct {
+	file  string
+	lines []int
+}
+
+func newErrParse(file string, lines ...int) *errParse {
+	return &errParse{file, lines}
+}
+
+func (e *errParse) Error() string {
+	return fmt.Sprintf("failed to parse %q at %v", e.file, e.lines)
+}
+
+func (e *errParse) Equal(other *errParse) bool {
+	if e == nil || other == nil {
+		return e == other
+	}
+	return e.file == other.file &&
+		reflect.DeepEqual(e.lines, other.lines)
+}
+
+func (*errParse) Is(e Err) bool { return e == ErrParse }
+
+type errRead struct {
+}
+
+func newErrRead() *errRead {
+	return &errRead{}
+}
+
+func (e *errRead) Error() string {
+	return fmt.Sprintf("failed to read")
+}
+
+func (e *errRead) Equal(other *errRead) bool {
+	if e == nil || other == nil {
+		return e == other
+	}
+	return true
+}
+
+func (*errRead) Is(e Err) bool { return e == ErrRead }
+
+type errSync struct {
+	causes []error
+}
+
+func newErrSync(causes ...error) *errSync {
+	return &errSync{causes}
+}
+
+func (e *errSync) Error() string {
+	if len(e.causes) == 0 {
+		return fmt.Sprintf("failed to sync")
+	}
+	return fmt.Sprintf("failed to sync: %s", _errJoin(e.causes))
+}
+
+func (e *errSync) Equal(other *errSync) bool {
+	if e == nil || other == nil {
+		return e == other
+	}
+	if len(e.causes) != len(other.causes) {
+		return false
+	}
+	for i, cause := range e.causes {
+		if !errors.Is(cause, other.causes[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *errSync) Unwrap() []error { return e.causes }
+
+func (*errSync) Is(e Err) bool { return e == ErrSync }`
+
+const causeFieldIn = `type Err string
+const (
+	ErrExec = Err("command {{cmd string %s}} failed: {{reason error %v}} (exit {{code int %d}})")
+	ErrRun  = Err("wrap:failed to run {{cmd string %s}}")
+)`
+
+const causeFieldOut = `type errExec struct {
+	cause error
+	cmd   string
+	code  int
+}
+
+func newErrExec(cmd string, reason error, code int) *errExec {
+	return &errExec{reason, cmd, code}
+}
+
+func (e *errExec) Error() string {
+	return fmt.Sprintf("command %s failed: %v (exit %d)", e.cmd, e.cause, e.code)
+}
+
+func (e *errExec) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (e *errExec) Unwrap() error { return e.cause }
+
+func (*errExec) Is(e Err) bool { return e == ErrExec }
+
+type errRun struct {
+	_errWrap
+	cmd string
+}
+
+func newErrRun(cmd string, err error) *errRun {
+	return &errRun{_errWrap{err}, cmd}
+}
+
+func (e *errRun) Error() string {
+	return fmt.Sprintf("failed to run %s: %v", e.cmd, e.cause)
+}
+
+func (e *errRun) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errRun) Is(e Err) bool { return e == ErrRun }`
+
+const optionsIn = `type Err string
+const ErrOpen = Err("open {{file string %q}} failed {{reason opt string %s}} after {{retries opt int %d}}")`
+
+const optionsOut = `type errOpen struct {
+	_errWrap
+	file    string
+	reason  string
+	retries int
+}
+
+func newErrOpen(file string, opts ...errOpenOption) *errOpen {
+	var o errOpen
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &errOpen{_errWrap{nil}, file, o.reason, o.retries}
+}
+
+// errOpenOption sets an optional field of errOpen.
+type errOpenOption func(*errOpen)
+
+// errOpenWithReason sets the optional field reason of errOpen.
+func errOpenWithReason(reason string) errOpenOption {
+	return func(e *errOpen) { e.reason = reason }
+}
+
+// errOpenWithRetries sets the optional field retries of errOpen.
+func errOpenWithRetries(retries int) errOpenOption {
+	return func(e *errOpen) { e.retries = retries }
+}
+
+func (e *errOpen) Error() string {
+	if e.cause == nil {
+		return e.errMsg()
+	}
+	return fmt.Sprintf("%s: %v", e.errMsg(), e.cause)
+}
+
+func (e *errOpen) errMsg() string {
+	var s string
+	s += fmt.Sprintf("open %q", e.file)
+	if e.reason != "" {
+		s += fmt.Sprintf(" failed %s", e.reason)
+	}
+	if e.retries != 0 {
+		s += fmt.Sprintf(" after %d", e.retries)
+	}
+	return s
+}
+
+func (e *errOpen) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }`
+
+const renameReservedIn = `type Err string
+const ErrKind = Err("unknown {{type string %q}} in {{func string %s}}")`
+
+const renameReservedOut = `type errKind struct {
+	_errWrap
+	type_ string
+	func_ string
+}
+
+func newErrKind(type_ string, func_ string) *errKind {
+	return &errKind{_errWrap{nil}, type_, func_}
+}
+
+func (e *errKind) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("unknown %q in %s", e.type_, e.func_)
+	}
+	return fmt.Sprintf("unknown %q in %s: %v", e.type_, e.func_, e.cause)
+}
+
+func (e *errKind) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errKind) Is(e Err) bool { return e == ErrKind }`
+
+const namedIn = `type Err string
+const (
+	// ErrOp is returned when an operation on a file fails.
+	// gorror-fields: op string %s, file string %q
+	ErrOp = Err("failed to {{.op}} {{.file}}")
+)`
+
+// The inline form of the named placeholders gives the same code.
+const namedInlineIn = `type Err string
+const (
+	// ErrOp is returned when an operation on a file fails.
+	ErrOp = Err("failed to {{op string %s}} {{file string %q}}")
+)`
+
+const namedOut = `// ErrOp is returned when an operation on a file fails.
+type errOp struct {
+	_errWrap
+	op   string
+	file string
+}
+
+// ErrOp is returned when an operation on a file fails.
+func newErrOp(op string, file string) *errOp {
+	return &errOp{_errWrap{nil}, op, file}
+}
+
+func (e *errOp) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to %s %q", e.op, e.file)
+	}
+	return fmt.Sprintf("failed to %s %q: %v", e.op, e.file, e.cause)
+}
+
+func (e *errOp) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errOp) Is(e Err) bool { return e == ErrOp }`
+
+const wrapCtorIn = `type Err string
+const (
+	ErrOpen  = Err("failed to open {{file string %q}}")
+	ErrClose = Err("wrap:failed to close {{file string %q}}")
+	ErrLimit = Err("nowrap:too many files")
+)`
+
+const wrapCtorOut = `type errOpen struct {
+	_errWrap
+	file string
+}
+
+func newErrOpen(file string) *errOpen {
+	return &errOpen{_errWrap{nil}, file}
+}
+
+// newErrOpenWrap is like newErrOpen, also wrapping a cause.
+func newErrOpenWrap(file string, err error) *errOpen {
+	return &errOpen{_errWrap{err}, file}
+}
+
+func (e *errOpen) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to open %q", e.file)
+	}
+	return fmt.Sprintf("failed to open %q: %v", e.file, e.cause)
+}
+
+func (e *errOpen) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }
+
+type errClose struct {
+	_errWrap
+	file string
+}
+
+func newErrClose(file string, err error) *errClose {
+	return &errClose{_errWrap{err}, file}
+}
+
+func (e *errClose) Error() string {
+	return fmt.Sprintf("failed to close %q: %v", e.file, e.cause)
+}
+
+func (e *errClose) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errClose) Is(e Err) bool { return e == ErrClose }
+
+type errLimit struct {
+}
+
+func newErrLimit() *errLimit {
+	return &errLimit{}
+}
+
+func (e *errLimit) Error() string {
+	return fmt.Sprintf("too many files")
+}
+
+func (*errLimit) Is(e Err) bool { return e == ErrLimit }`
+
+const structPrefixIn = `type Err string
+const ErrOpen = Err("failed to open {{file string %q}}")`
+
+const structPrefixOut = `type genErrOpen struct {
+	_errWrap
+	file string
+}
+
+func newGenErrOpen(file string) *genErrOpen {
+	return &genErrOpen{_errWrap{nil}, file}
+}
+
+func (e *genErrOpen) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to open %q", e.file)
+	}
+	return fmt.Sprintf("failed to open %q: %v", e.file, e.cause)
+}
+
+func (e *genErrOpen) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (e *genErrOpen) Equal(other *genErrOpen) bool {
+	if e == nil || other == nil {
+		return e == other
+	}
+	return e.file == other.file &&
+		errors.Is(e.cause, other.cause)
+}
+
+func (*genErrOpen) Is(e Err) bool { return e == ErrOpen }`
+
+const structPrefixPubOut = `type GenErrOpen struct {
+	_errWrap
+	file string
+}
+
+func NewGenErrOpen(file string) *GenErrOpen {
+	return &GenErrOpen{_errWrap{nil}, file}
+}
+
+func (e *GenErrOpen) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to open %q", e.file)
+	}
+	return fmt.Sprintf("failed to open %q: %v", e.file, e.cause)
+}
+
+func (e *GenErrOpen) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (e *GenErrOpen) Equal(other *GenErrOpen) bool {
+	if e == nil || other == nil {
+		return e == other
+	}
+	return e.file == other.file &&
+		errors.Is(e.cause, other.cause)
+}
+
+func (*GenErrOpen) Is(e Err) bool { return e == ErrOpen }`
+
+const emptyMessageIn = `type Err string
+const (
+	ErrThin  = Err("wrap:")
+	ErrNone  = Err("nowrap:")
+	ErrMaybe = Err("")
+	ErrMany  = Err("multiwrap:")
+)`
+
+const emptyMessageOut = `type errThin struct {
+	_errWrap
+}
+
+func newErrThin(err error) *errThin {
+	return &errThin{_errWrap{err}}
+}
+
+func (e *errThin) Error() string {
+	return fmt.Sprintf("%v", e.cause)
+}
+
+func (e *errThin) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errThin) Is(e Err) bool { return e == ErrThin }
+
+type errNone struct {
+}
+
+func newErrNone() *errNone {
+	return &errNone{}
+}
+
+func (e *errNone) Error() string {
+	return ""
+}
+
+func (*errNone) Is(e Err) bool { return e == ErrNone }
+
+type errMaybe struct {
+	_errWrap
+}
+
+func newErrMaybe() *errMaybe {
+	return &errMaybe{_errWrap{nil}}
+}
+
+func (e *errMaybe) Error() string {
+	if e.cause == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", e.cause)
+}
+
+func (e *errMaybe) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errMaybe) Is(e Err) bool { return e == ErrMaybe }
+
+type errMany struct {
+	causes []error
+}
+
+func newErrMany(causes ...error) *errMany {
+	return &errMany{causes}
+}
+
+func (e *errMany) Error() string {
+	return _errJoin(e.causes)
+}
+
+func (e *errMany) Unwrap() []error { return e.causes }
+
+func (*errMany) Is(e Err) bool { return e == ErrMany }`
+
+const contextIn = `type Err string
+const (
+	ErrOpen  = Err("failed to open {{file string %q}}")
+	ErrClose = Err("nowrap:failed to close")
+)`
+
+const contextOut = `type errOpen struct {
+	_errWrap
+	file string
+	ctx  map[string]interface{}
+}
+
+func newErrOpen(file string) *errOpen {
+	return &errOpen{_errWrap{nil}, file, nil}
+}
+
+func (e *errOpen) Error() string { return e.formatMsg() + _errContext(e.ctx) }
+
+func (e *errOpen) formatMsg() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to open %q", e.file)
+	}
+	return fmt.Sprintf("failed to open %q: %v", e.file, e.cause)
+}
+
+func (e *errOpen) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (e *errOpen) With(key string, val interface{}) *errOpen {
+	if e.ctx == nil {
+		e.ctx = make(map[string]interface{})
+	}
+	e.ctx[key] = val
+	return e
+}
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }
+
+type errClose struct {
+	ctx map[string]interface{}
+}
+
+func newErrClose() *errClose {
+	return &errClose{nil}
+}
+
+func (e *errClose) Error() string { return e.formatMsg() + _errContext(e.ctx) }
+
+func (e *errClose) formatMsg() string {
+	return fmt.Sprintf("failed to close")
+}
+
+func (e *errClose) With(key string, val interface{}) *errClose {
+	if e.ctx == nil {
+		e.ctx = make(map[string]interface{})
+	}
+	e.ctx[key] = val
+	return e
+}
+
+func (*errClose) Is(e Err) bool { return e == ErrClose }`
+
+const emptyMessageFmtModesOut = `type errThin struct {
+	_errWrap
+}
+
+func newErrThin(err error) *errThin {
+	return &errThin{_errWrap{err}}
+}
+
+func (e *errThin) Error() string {
+	return fmt.Sprintf("%v", e.cause)
+}
+
+func (e *errThin) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (e *errThin) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 's':
+	case 'v':
+		if f.Flag('+') && e.cause != nil {
+			fmt.Fprintf(f, "%+v", e.cause)
+			return
+		}
+		fmt.Fprint(f, e.Error())
+	default:
+		fmt.Fprintf(f, "%"+string(verb), e.Error())
+	}
+}
+
+func (*errThin) Is(e Err) bool { return e == ErrThin }
+
+type errNone struct {
+}
+
+func newErrNone() *errNone {
+	return &errNone{}
+}
+
+func (e *errNone) Error() string {
+	return ""
+}
+
+func (e *errNone) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 's', 'v':
+		fmt.Fprint(f, e.Error())
+	default:
+		fmt.Fprintf(f, "%"+string(verb), e.Error())
+	}
+}
+
+func (*errNone) Is(e Err) bool { return e == ErrNone }
+
+type errMaybe struct {
+	_errWrap
+}
+
+func newErrMaybe() *errMaybe {
+	return &errMaybe{_errWrap{nil}}
+}
+
+func (e *errMaybe) Error() string {
+	if e.cause == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", e.cause)
+}
+
+func (e *errMaybe) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (e *errMaybe) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 's':
+	case 'v':
+		if f.Flag('+') && e.cause != nil {
+			fmt.Fprintf(f, "%+v", e.cause)
+			return
+		}
+		fmt.Fprint(f, e.Error())
+	default:
+		fmt.Fprintf(f, "%"+string(verb), e.Error())
+	}
+}
+
+func (*errMaybe) Is(e Err) bool { return e == ErrMaybe }
+
+type errMany struct {
+	causes []error
+}
+
+func newErrMany(causes ...error) *errMany {
+	return &errMany{causes}
+}
+
+func (e *errMany) Error() string {
+	return _errJoin(e.causes)
+}
+
+func (e *errMany) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 's', 'v':
+		fmt.Fprint(f, e.Error())
+	default:
+		fmt.Fprintf(f, "%"+string(verb), e.Error())
+	}
+}
+
+func (e *errMany) Unwrap() []error { return e.causes }
+
+func (*errMany) Is(e Err) bool { return e == ErrMany }`
+
+const emptyMessageCLIOut = `type errThin struct {
+	_errWrap
+}
+
+func newErrThin(err error) *errThin {
+	return &errThin{_errWrap{err}}
+}
+
+func (e *errThin) Error() string {
+	return fmt.Sprintf("%v", e.cause)
+}
+
+func (e *errThin) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (e *errThin) CLIString() string {
+	if e.cause == nil {
+		return ""
+	}
+	return "\x1b[2m" + e.cause.Error() + "\x1b[0m"
+}
+
+func (*errThin) Is(e Err) bool { return e == ErrThin }
+
+type errNone struct {
+}
+
+func newErrNone() *errNone {
+	return &errNone{}
+}
+
+func (e *errNone) Error() string {
+	return ""
+}
+
+func (e *errNone) CLIString() string {
+	return "\x1b[31m" + e.Error() + "\x1b[0m"
+}
+
+func (*errNone) Is(e Err) bool { return e == ErrNone }
+
+type errMaybe struct {
+	_errWrap
+}
+
+func newErrMaybe() *errMaybe {
+	return &errMaybe{_errWrap{nil}}
+}
+
+func (e *errMaybe) Error() string {
+	if e.cause == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", e.cause)
+}
+
+func (e *errMaybe) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (e *errMaybe) CLIString() string {
+	if e.cause == nil {
+		return ""
+	}
+	return "\x1b[2m" + e.cause.Error() + "\x1b[0m"
+}
+
+func (*errMaybe) Is(e Err) bool { return e == ErrMaybe }
+
+type errMany struct {
+	causes []error
+}
+
+func newErrMany(causes ...error) *errMany {
+	return &errMany{causes}
+}
+
+func (e *errMany) Error() string {
+	return _errJoin(e.causes)
+}
+
+func (e *errMany) CLIString() string {
+	if len(e.causes) == 0 {
+		return ""
+	}
+	return "\x1b[2m" + _errJoin(e.causes) + "\x1b[0m"
+}
+
+func (e *errMany) Unwrap() []error { return e.causes }
+
+func (*errMany) Is(e Err) bool { return e == ErrMany }`
+
+const patternUnicodeIn = `type Err string
+const ErrName = Err("invalid name {{ñame string %s /^[a-z]+$/}}")`
+
+const patternUnicodeOut = `type errName struct {
+	_errWrap
+	ñame string
+}
+
+var _errNameÑameRE = regexp.MustCompile("^[a-z]+$")
+
+func newErrName(ñame string) *errName {
+	if !_errNameÑameRE.MatchString(ñame) {
+		panic(fmt.Sprintf("newErrName: ñame %q does not match %s", ñame, _errNameÑameRE))
+	}
+	return &errName{_errWrap{nil}, ñame}
+}
+
+func (e *errName) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("invalid name %s", e.ñame)
+	}
+	return fmt.Sprintf("invalid name %s: %v", e.ñame, e.cause)
+}
+
+func (e *errName) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errName) Is(e Err) bool { return e == ErrName }`
+
+const patternOptIn = `type Err string
+const ErrDial = Err("failed to dial {{addr string %s}} {{code opt string %s /^[A-Z]{3}$/}}")`
+
+const patternOptOut = `type errDial struct {
+	_errWrap
+	addr string
+	code string
+}
+
+var _errDialCodeRE = regexp.MustCompile("^[A-Z]{3}$")
+
+func newErrDial(addr string, code string) *errDial {
+	if code != "" && !_errDialCodeRE.MatchString(code) {
+		panic(fmt.Sprintf("newErrDial: code %q does not match %s", code, _errDialCodeRE))
+	}
+	return &errDial{_errWrap{nil}, addr, code}
+}
+
+func (e *errDial) Error() string {
+	if e.cause == nil {
+		return e.errMsg()
+	}
+	return fmt.Sprintf("%s: %v", e.errMsg(), e.cause)
+}
+
+func (e *errDial) errMsg() string {
+	var s string
+	s += fmt.Sprintf("failed to dial %s", e.addr)
+	if e.code != "" {
+		s += fmt.Sprintf(" %s", e.code)
+	}
+	return s
+}
+
+func (e *errDial) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errDial) Is(e Err) bool { return e == ErrDial }`
+
+const patternOptionsOut = `type errDial struct {
+	_errWrap
+	addr string
+	code string
+}
+
+var _errDialCodeRE = regexp.MustCompile("^[A-Z]{3}$")
+
+func newErrDial(addr string, opts ...errDialOption) *errDial {
+	var o errDial
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.code != "" && !_errDialCodeRE.MatchString(o.code) {
+		panic(fmt.Sprintf("newErrDial: code %q does not match %s", o.code, _errDialCodeRE))
+	}
+	return &errDial{_errWrap{nil}, addr, o.code}
+}
+
+// errDialOption sets an optional field of errDial.
+type errDialOption func(*errDial)
+
+// errDialWithCode sets the optional field code of errDial.
+func errDialWithCode(code string) errDialOption {
+	return func(e *errDial) { e.code = code }
+}
+
+func (e *errDial) Error() string {
+	if e.cause == nil {
+		return e.errMsg()
+	}
+	return fmt.Sprintf("%s: %v", e.errMsg(), e.cause)
+}
+
+func (e *errDial) errMsg() string {
+	var s string
+	s += fmt.Sprintf("failed to dial %s", e.addr)
+	if e.code != "" {
+		s += fmt.Sprintf(" %s", e.code)
+	}
+	return s
+}
+
+func (e *errDial) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (*errDial) Is(e Err) bool { return e == ErrDial }`
+
+const reqIDCollisionIn = `type Err string
+const ErrCancel = Err("canceled {{ctx string %s}} at {{e int %d}}")`
+
+const reqIDCollisionOut = `type errCancel struct {
+	_errWrap
+	ctx       string
+	e         int
+	requestID string
+}
+
+func newErrCancel(ctx string, e int) *errCancel {
+	return &errCancel{_errWrap{nil}, ctx, e, ""}
+}
+
+func newErrCancelCtx(_ctx context.Context, ctx string, e int) *errCancel {
+	_e := &errCancel{_errWrap{nil}, ctx, e, ""}
+	_e.requestID, _ = _ctx.Value(requestIDKey).(string)
+	return _e
+}
+
+func (e *errCancel) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("canceled %s at %d", e.ctx, e.e)
+	}
+	return fmt.Sprintf("canceled %s at %d: %v", e.ctx, e.e, e.cause)
+}
+
+func (e *errCancel) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (e *errCancel) RequestID() string { return e.requestID }
+
+func (*errCancel) Is(e Err) bool { return e == ErrCancel }`
+
+const cacheMsgCollisionIn = `type Err string
+const ErrLimit = Err("limit {{e int %d}} exceeded")`
+
+const cacheMsgCollisionOut = `type errLimit struct {
+	_errWrap
+	e   int
+	msg string
+}
+
+func newErrLimit(e int) *errLimit {
+	_e := &errLimit{_errWrap{nil}, e, ""}
+	_e.msg = _e.formatMsg()
+	return _e
+}
+
+func (e *errLimit) Error() string { return e.msg }
+
+func (e *errLimit) formatMsg() string {
+	if e.cause == nil {
+		return fmt.Sprintf("limit %d exceeded", e.e)
+	}
+	return fmt.Sprintf("limit %d exceeded: %v", e.e, e.cause)
+}
+
+func (e *errLimit) Wrap(cause error) error {
+	e.cause = cause
+	e.msg = e.formatMsg()
+	return e
+}
+
+func (*errLimit) Is(e Err) bool { return e == ErrLimit }`
+
+const contextRenderIn = `type Err string
+const (
+	ErrOpen = Err("failed to open {{file string %q}}")
+	ErrThin = Err("wrap:")
+)`
+
+const contextFmtModesOut = `type errOpen struct {
+	_errWrap
+	file string
+	ctx  map[string]interface{}
+}
+
+func newErrOpen(file string) *errOpen {
+	return &errOpen{_errWrap{nil}, file, nil}
+}
+
+func (e *errOpen) Error() string { return e.formatMsg() + _errContext(e.ctx) }
+
+func (e *errOpen) formatMsg() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to open %q", e.file)
+	}
+	return fmt.Sprintf("failed to open %q: %v", e.file, e.cause)
+}
+
+func (e *errOpen) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (e *errOpen) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 's':
+		fmt.Fprintf(f, "failed to open %q%s", e.file, _errContext(e.ctx))
+	case 'v':
+		if f.Flag('+') && e.cause != nil {
+			fmt.Fprintf(f, "failed to open %q: %+v%s", e.file, e.cause, _errContext(e.ctx))
+			return
+		}
+		fmt.Fprint(f, e.Error())
+	default:
+		fmt.Fprintf(f, "%"+string(verb), e.Error())
+	}
+}
+
+func (e *errOpen) With(key string, val interface{}) *errOpen {
+	if e.ctx == nil {
+		e.ctx = make(map[string]interface{})
+	}
+	e.ctx[key] = val
+	return e
+}
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }
+
+type errThin struct {
+	_errWrap
+	ctx map[string]interface{}
+}
+
+func newErrThin(err error) *errThin {
+	return &errThin{_errWrap{err}, nil}
+}
+
+func (e *errThin) Error() string { return e.formatMsg() + _errContext(e.ctx) }
+
+func (e *errThin) formatMsg() string {
+	return fmt.Sprintf("%v", e.cause)
+}
+
+func (e *errThin) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (e *errThin) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 's':
+		fmt.Fprint(f, _errContext(e.ctx))
+	case 'v':
+		if f.Flag('+') && e.cause != nil {
+			fmt.Fprintf(f, "%+v%s", e.cause, _errContext(e.ctx))
+			return
+		}
+		fmt.Fprint(f, e.Error())
+	default:
+		fmt.Fprintf(f, "%"+string(verb), e.Error())
+	}
+}
+
+func (e *errThin) With(key string, val interface{}) *errThin {
+	if e.ctx == nil {
+		e.ctx = make(map[string]interface{})
+	}
+	e.ctx[key] = val
+	return e
+}
+
+func (*errThin) Is(e Err) bool { return e == ErrThin }`
+
+const contextCLIOut = `type errOpen struct {
+	_errWrap
+	file string
+	ctx  map[string]interface{}
+}
+
+func newErrOpen(file string) *errOpen {
+	return &errOpen{_errWrap{nil}, file, nil}
+}
+
+func (e *errOpen) Error() string { return e.formatMsg() + _errContext(e.ctx) }
+
+func (e *errOpen) formatMsg() string {
+	if e.cause == nil {
+		return fmt.Sprintf("failed to open %q", e.file)
+	}
+	return fmt.Sprintf("failed to open %q: %v", e.file, e.cause)
+}
+
+func (e *errOpen) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (e *errOpen) CLIString() string {
+	s := fmt.Sprintf("failed to open %q", e.file)
+	if e.cause != nil {
+		s += ": " + e.cause.Error()
+	}
+	return s + _errContext(e.ctx)
+}
+
+func (e *errOpen) GoString() string {
+	return fmt.Sprintf("errOpen{file: %#v, cause: %#v, ctx: %#v}", e.file, e.cause, e.ctx)
+}
+
+func (e *errOpen) With(key string, val interface{}) *errOpen {
+	if e.ctx == nil {
+		e.ctx = make(map[string]interface{})
+	}
+	e.ctx[key] = val
+	return e
+}
+
+func (*errOpen) Is(e Err) bool { return e == ErrOpen }
+
+type errThin struct {
+	_errWrap
+	ctx map[string]interface{}
+}
+
+func newErrThin(err error) *errThin {
+	return &errThin{_errWrap{err}, nil}
+}
+
+func (e *errThin) Error() string { return e.formatMsg() + _errContext(e.ctx) }
+
+func (e *errThin) formatMsg() string {
+	return fmt.Sprintf("%v", e.cause)
+}
+
+func (e *errThin) Wrap(cause error) error {
+	e.cause = cause
+	return e
+}
+
+func (e *errThin) CLIString() string {
+	if e.cause == nil {
+		return _errContext(e.ctx)
+	}
+	return e.cause.Error() + _errContext(e.ctx)
+}
+
+func (e *errThin) GoString() string {
+	return fmt.Sprintf("errThin{cause: %#v, ctx: %#v}", e.cause, e.ctx)
+}
+
+func (e *errThin) With(key string, val interface{}) *errThin {
+	if e.ctx == nil {
+		e.ctx = make(map[string]interface{})
+	}
+	e.ctx[key] = val
+	return e
+}
+
+func (*errThin) Is(e Err) bool { return e == ErrThin }`
+
 func TestGolden(t *testing.T) {
 	for _, test := range golden {
 		t.Run(test.name, func(t *testing.T) {
@@ -210,12 +3406,20 @@ func TestGolden(t *testing.T) {
 				t.Fatalf("%s: need type declaration on first line", test.name)
 			}
 
-			g := Generator{typeName: tokens[1], compatIs: test.compatIs}
-			g.loadPackage([]string{absFile})
+			g := test.gen
+			g.typeName = tokens[1]
+			if err := g.loadPackage([]string{absFile}); err != nil {
+				t.Fatal(err)
+			}
 			for _, e := range g.specs {
 				g.generate(e)
 			}
-			got := string(g.format())
+			g.footer()
+			src, err := g.format()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := string(src)
 			expected := test.output + "\n\n"
 			if got != expected {
 				t.Errorf("%s: got(%d)\n====\n%q====\nexpected(%d)\n====\n%q",
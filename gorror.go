@@ -10,45 +10,151 @@ package main
 import (
 	"bytes"
 	_ "embed"
+	"errors"
 	"flag"
 	"fmt"
 	"go/ast"
+	"go/build"
+	"go/build/constraint"
 	"go/format"
 	"go/parser"
 	"go/token"
+	"go/types"
+	"io"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"unicode"
+	"unicode/utf8"
 
 	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/imports"
 )
 
 var (
-	flagTyp    = flag.String("type", "", "type of the error specifications; required")
-	flagOut    = flag.String("output", "", "output file name; default srcdir/<type>_def.go")
-	flagIs     = flag.Bool("is", false, "enable compatibility with errors.Is")
-	flagPub    = flag.Bool("P", false, "generate public errors")
-	flagSuffix = flag.String("suffix", "", "to drop from the end of the error specs")
-	flagImps   = flag.String("import", "", "comma-separated list of imports")
+	flagTyp         = flag.String("type", "", "comma-separated list of types of the error specifications; required")
+	flagOut         = flag.String("output", "", "output file name; default srcdir/<type><suffix>, see -output-suffix")
+	flagIs          = flag.Bool("is", false, "enable compatibility with errors.Is")
+	flagPub         = flag.Bool("P", false, "generate public errors")
+	flagSuffix      = flag.String("suffix", "", "to drop from the end of the error specs")
+	flagImps        = flag.String("import", "", "comma-separated list of imports, each as path or alias=path")
+	flagStack       = flag.Bool("stack", false, "capture a stack trace when constructing errors")
+	flagCodeM       = flag.Bool("code-matches", false, "generate CodeMatches to compare with string codes")
+	flagReg         = flag.Bool("registry", false, "generate a slice with all the errors of the type")
+	flagDryRun      = flag.Bool("dry-run", false, "print the generated code instead of writing it")
+	flagSupp        = flag.Bool("suppressed", false, "generate errors that hold additional suppressed errors")
+	flagImmut       = flag.Bool("immutable", false, "make Wrap return a wrapped copy of the error")
+	flagCoverage    = flag.Bool("check-coverage", false, "fail if any constant of the type is not generated")
+	flagQuiet       = flag.Bool("quiet", false, "do not log warnings and informative messages")
+	flagConfig      = flag.String("config", "", "JSON file with default flag values, e.g. {\"type\": \"Err\"}; default the nearest "+configName+" in the working directory or its parents")
+	flagWrapped     = flag.Bool("wrapped-accessor", false, "generate a Wrapped method returning the cause")
+	flagPkg         = flag.String("pkg", "", "package name of the generated file; default is the source package")
+	flagFmtModes    = flag.Bool("fmt-modes", false, "implement fmt.Formatter: %s without cause, %v and %+v with it")
+	flagReqID       = flag.String("reqid", "", "context key of request IDs; when set errors carry a request ID")
+	flagSplit       = flag.Bool("split", false, "write the errors of each type to <type>_def.go and common code to gorror_common.go")
+	flagFast        = flag.Bool("fast", false, "generate Error methods with a strings.Builder instead of fmt.Sprintf")
+	flagTestHelp    = flag.Bool("test-helpers", false, "generate SameKind to compare the kinds of two errors in tests")
+	flagCauseFirst  = flag.Bool("cause-first-ctor", false, "take the cause of wrap: errors as the first constructor parameter")
+	flagCLI         = flag.Bool("cli-method", false, "generate CLIString returning the message with ANSI colors for terminals")
+	flagCLIColor    = flag.String("cli-color", "31", "ANSI SGR parameters of the message color in CLIString, empty for no colors")
+	flagGoString    = flag.Bool("gostring", false, "generate GoString methods for a compact %#v representation")
+	flagCtorMap     = flag.Bool("ctor-map", false, "generate a map from each error constant to a constructor taking positional arguments")
+	flagWrapVerb    = flag.String("wrap-verb", "%v", "formatting verb of the cause appended to messages, e.g. %+v or %s")
+	flagLogOnce     = flag.Bool("log-once", false, "generate MarkLogged, reporting true only the first time an error is marked")
+	flagIsFunc      = flag.Bool("is-func", false, "generate an IsX(err error) bool function for each error")
+	flagSepComm     = flag.Bool("sep-comment", false, "emit a // ---- Name ---- divider comment before each error")
+	flagGoimp       = flag.Bool("goimports", false, "format the output with goimports, adding missing and removing unused imports")
+	flagMeta        = flag.Bool("metadata", false, "generate errors carrying string metadata, set with WithMeta and read with Meta")
+	flagWrapType    = flag.String("wrap-type", "_errWrap", "name of the type embedded by errors to hold the cause")
+	flagCacheMsg    = flag.Bool("cache-msg", false, "compute messages when constructing and wrapping errors, instead of in Error")
+	flagWatch       = flag.Bool("watch", false, "after generating, watch the sources and regenerate when they change")
+	flagAsHelpers   = flag.Bool("as-helpers", false, "generate an AsX(err error) (*X, bool) function for each error")
+	flagBuildTags   = flag.String("build-tags", "", "comma-separated list of build tags to consider when loading the package")
+	flagGOOS        = flag.String("goos", "", "GOOS to consider when loading the package, default is the host one")
+	flagGOARCH      = flag.String("goarch", "", "GOARCH to consider when loading the package, default is the host one")
+	flagBuildCons   = flag.String("build-constraint", "", "build constraint of the generated file, e.g. \"linux && amd64\"")
+	flagText        = flag.Bool("text", false, "implement encoding.TextMarshaler, marshaling errors to their message")
+	flagMatchFields = flag.Bool("match-fields", false, "with -is, make Is also match errors of the same kind with equal fields")
+	flagRetIface    = flag.Bool("return-interface", false, "make constructors return error, or an interface with Wrap for errors that wrap")
+	flagCodeEnum    = flag.Bool("code-enum", false, "generate a <type>Code enum with a constant per error, returned by Code methods")
+	flagCodeBase    = flag.Int("code-base", 1, "first code assigned to errors without a code: directive, with -code-enum")
+	flagOutSuffix   = flag.String("output-suffix", "_def.go", "suffix of the default output file name, appended to the lowercase type name")
+	flagStdin       = flag.Bool("stdin", false, "read the source of a single file from stdin, like giving - as argument, and print the generated code")
+	flagShort       = flag.Bool("short", false, "append only the own message of the immediate cause in Error, instead of the whole chain")
+	flagNoIs        = flag.Bool("no-is", false, "omit the Is methods of the errors and the IsIn method of the error type")
+	flagStringer    = flag.Bool("stringer", false, "generate a String method returning the message, implementing fmt.Stringer")
+	flagWrapParam   = flag.String("wrap-param", "err", "name of the cause parameter of the constructors of wrap: errors, replaced when a field has the same name")
+	flagInterface   = flag.String("interface", "", "interface the errors implement, asserted at compile time, as Name, pkg.Name or import/path.Name")
+	flagDomain      = flag.String("domain", "", "value returned by the generated Domain() string methods, if given")
+	flagCauser      = flag.Bool("causer", false, "generate a Cause method returning the cause, for github.com/pkg/errors compatibility")
+	flagSentinels   = flag.Bool("sentinels", false, "generate a shared XInstance variable for each nowrap: error without fields")
+	flagEqual       = flag.Bool("equal", false, "generate an Equal method comparing the fields and the cause of two errors")
+	flagSummary     = flag.Bool("summary", false, "log a summary of the generated errors at the end of the run; always on with -verbose")
+	flagOptions     = flag.Bool("options", false, "set optional fields with functional options passed to the constructors")
+	flagInplace     = flag.Bool("inplace", false, "replace only the code between // gorror:begin and // gorror:end in the output file, appending it if missing")
+	flagRenameRes   = flag.Bool("rename-reserved", false, "rename fields named after Go keywords, appending an underscore, instead of failing")
+	flagNolint      = flag.String("nolint", "", "comma-separated list of linters, or all, disabled on the generated files with a //nolint directive")
+	flagNamed       = flag.Bool("named", false, "reference fields as {{.name}} in templates, declared in a // gorror-fields: comment")
+	flagWrapCtor    = flag.Bool("wrap-ctor", false, "generate constructors also taking the cause for errors that optionally wrap, see -wrap-ctor-suffix")
+	flagWrapCtorSfx = flag.String("wrap-ctor-suffix", "Wrap", "suffix of the names of the constructors generated with -wrap-ctor")
+	flagMinGo       = flag.String("min-go", "", "oldest Go version supported by the generated files, e.g. go1.18, which are constrained to it; fail when a feature needs a newer one")
+	flagStructPfx   = flag.String("struct-prefix", "", "prefix of the names of the generated types, e.g. gen for genErrOpen")
+	flagStrict      = flag.Bool("strict", false, "fail instead of warning when the verbs of a message do not match its fields")
+	flagContext     = flag.Bool("context", false, "generate errors carrying key/value context, set with With and appended to messages")
+	flagVerb        bool
 )
 
+func init() {
+	const usage = "log the discovered error specifications"
+	flag.BoolVar(&flagVerb, "v", false, usage)
+	flag.BoolVar(&flagVerb, "verbose", false, usage)
+	flag.StringVar(flagPkg, "package", "", "alias of -pkg")
+}
+
 //go:embed banner.txt
 var banner string
 
 //go:embed VERSION
 var version string
 
-var tmplRE = regexp.MustCompile(`{{([A-Za-z0-9_\.\[\]]+) (\*?[A-Za-z0-9_\.]+) (%[A-Za-z0-9#\.\+]+)}}`)
+// generatedHeader is the first line of the generated files, in the form recognized by Go tools
+// (see https://golang.org/s/generatedcode).
+const generatedHeader = "// Code generated by gorror. DO NOT EDIT."
+
+// legacyHeader is the first line of the files generated by previous versions.
+const legacyHeader = "// Errors generated by Gorror; DO NOT EDIT."
+
+// causeToken is the placeholder for the cause in a template.
+const causeToken = "{{cause}}"
+
+// verbPattern matches a formatting verb with its flags, width and precision. Explicit argument
+// indexes and widths or precisions taken from arguments are not supported, since each field
+// is formatted by a single argument.
+const verbPattern = `%[\+\-# 0]*[0-9]*(?:\.[0-9]*)?[A-Za-z]`
+
+// tmplRE matches a field placeholder, optionally marked opt and followed by a /pattern/
+// validating its value. Expressions and types may contain Unicode letters and digits, as Go
+// identifiers do.
+var tmplRE = regexp.MustCompile(`{{([\pL\p{Nd}_\.\[\]\(\),]+) (?:(opt) )?((?:\.\.\.)?\*?[\pL\p{Nd}_\.]+) (` +
+	verbPattern + `)(?: /((?:[^/\\]|\\.)+)/)?}}`)
+
+// nolintRE matches the list of linters given with -nolint.
+var nolintRE = regexp.MustCompile(`^[\w-]+(,[\w-]+)*$`)
+
+// verbRE matches a single formatting verb.
+var verbRE = regexp.MustCompile(`^` + verbPattern + `$`)
 
 func Usage() {
 	fmt.Fprintf(os.Stderr, "\n%s\nVer: %s\n\n", banner, version)
 	fmt.Fprintf(os.Stderr, "Usage of Gorror:\n")
-	fmt.Fprintf(os.Stderr, "\tgorror [flags] -type T [directory]\n")
+	fmt.Fprintf(os.Stderr, "\tgorror [flags] -type T [directories...]\n")
 	fmt.Fprintf(os.Stderr, "\tgorror [flags] -type T files... # Must be a single package\n")
 	fmt.Fprintf(os.Stderr, "Flags:\n")
 	flag.PrintDefaults()
@@ -60,19 +166,145 @@ func main() {
 	flag.Usage = Usage
 	flag.Parse()
 
+	if *flagConfig == "" {
+		if wd, err := os.Getwd(); err == nil {
+			*flagConfig, _ = findConfig(wd)
+		}
+		if *flagConfig != "" && flagVerb {
+			log.Printf("using config %s", *flagConfig)
+		}
+	}
+	if *flagConfig != "" {
+		if err := applyConfig(flag.CommandLine, *flagConfig); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	if *flagTyp == "" {
 		flag.Usage()
 		os.Exit(1)
 	}
 
-	args := flag.Args()
+	if err := execute(flag.Args()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// execute validates the flags and generates the errors of the package or files given in args,
+// then watches them with -watch. It returns the first error, leaving exiting to the caller.
+func execute(args []string) error {
+	if strings.Trim(*flagCLIColor, "0123456789;") != "" {
+		return fmt.Errorf("invalid ANSI color %q, expected SGR parameters such as 31 or 1;31", *flagCLIColor)
+	}
+
+	if !verbRE.MatchString(*flagWrapVerb) {
+		return fmt.Errorf("invalid -wrap-verb %q, expected a single verb such as %%v", *flagWrapVerb)
+	}
+
+	if !token.IsIdentifier(*flagWrapType) {
+		return fmt.Errorf("invalid -wrap-type %q", *flagWrapType)
+	}
+
+	if *flagWrapCtor && !token.IsIdentifier("_"+*flagWrapCtorSfx) {
+		return fmt.Errorf("invalid -wrap-ctor-suffix %q", *flagWrapCtorSfx)
+	}
+
+	if !token.IsIdentifier(*flagWrapParam) {
+		return fmt.Errorf("invalid -wrap-param %q", *flagWrapParam)
+	}
+
+	if *flagInterface != "" {
+		name := (*flagInterface)[strings.LastIndexByte(*flagInterface, '.')+1:]
+		if !token.IsIdentifier(name) {
+			return fmt.Errorf("invalid -interface %q", *flagInterface)
+		}
+	}
+
+	if *flagBuildCons != "" {
+		if _, err := constraint.Parse("//go:build " + *flagBuildCons); err != nil {
+			return fmt.Errorf("invalid -build-constraint %q: %w", *flagBuildCons, err)
+		}
+	}
+
+	if *flagStructPfx != "" && !token.IsIdentifier(*flagStructPfx) {
+		return fmt.Errorf("invalid -struct-prefix %q", *flagStructPfx)
+	}
+
+	if *flagMinGo != "" && !goVersionRE.MatchString(*flagMinGo) {
+		return fmt.Errorf("invalid -min-go %q, expected a version like go1.18", *flagMinGo)
+	}
+
+	if *flagMatchFields && !*flagIs {
+		return errors.New("-match-fields requires -is")
+	}
+
+	if *flagNoIs && (*flagIs || *flagTestHelp) {
+		return errors.New("-no-is cannot be used with -is or -test-helpers")
+	}
+
+	if !strings.HasSuffix(*flagOutSuffix, ".go") {
+		return fmt.Errorf("invalid -output-suffix %q, expected a .go suffix", *flagOutSuffix)
+	}
+
+	if *flagNolint != "" && !nolintRE.MatchString(*flagNolint) {
+		return fmt.Errorf("invalid -nolint %q, expected a comma-separated list of linters", *flagNolint)
+	}
+
+	if *flagPkg != "" && !token.IsIdentifier(*flagPkg) {
+		return fmt.Errorf("invalid package name %q", *flagPkg)
+	}
+
+	if *flagStdin {
+		args = []string{"-"}
+	}
 	if len(args) < 1 {
 		args = []string{"."}
 	}
 
+	var stdin []byte
+	if len(args) == 1 && args[0] == "-" {
+		if *flagWatch || *flagSplit || *flagInplace {
+			return errors.New("-watch, -split and -inplace cannot be used when reading from stdin")
+		}
+		var err error
+		if stdin, err = io.ReadAll(os.Stdin); err != nil {
+			return fmt.Errorf("reading stdin: %w", err)
+		}
+	}
+
+	var dirs []string
+	if stdin == nil {
+		for _, arg := range args {
+			isDir, err := isDirectory(arg)
+			if err != nil {
+				return err
+			}
+			if isDir {
+				dirs = append(dirs, arg)
+			}
+		}
+		if len(dirs) > 0 && len(dirs) < len(args) {
+			return errors.New("cannot mix directories and files")
+		}
+		if len(dirs) > 1 && (*flagWatch || *flagOut != "") {
+			return errors.New("-watch and -output cannot be used with several directories")
+		}
+		if len(dirs) == 0 {
+			if err := checkFiles(args); err != nil {
+				return err
+			}
+		}
+	}
+	if *flagSplit && *flagOut != "" {
+		return errors.New("-output cannot be used with -split")
+	}
+	if *flagSplit && *flagInplace {
+		return errors.New("-inplace cannot be used with -split")
+	}
+
 	var dir string
-	if len(args) == 1 && isDirectory(args[0]) {
-		dir = args[0]
+	if len(dirs) == 1 {
+		dir = dirs[0]
 	} else {
 		dir = filepath.Dir(args[0])
 	}
@@ -81,353 +313,3192 @@ func main() {
 	for _, s := range strings.Split(*flagImps, ",") {
 		s = strings.TrimSpace(s)
 		if len(s) > 0 {
+			if alias, _ := splitImport(s); strings.Contains(s, "=") && !token.IsIdentifier(alias) {
+				return fmt.Errorf("invalid import alias %q", alias)
+			}
 			imports = append(imports, s)
 		}
 	}
 	sort.Strings(imports)
 
-	g := Generator{
-		typeName:   *flagTyp,
-		compatIs:   *flagIs,
-		makePub:    *flagPub,
-		specSuffix: *flagSuffix,
-		imports:    imports,
+	outDir := dir
+	if *flagOut != "" {
+		outDir = filepath.Dir(*flagOut)
 	}
 
-	g.loadPackage(args)
-
-	if len(g.specs) < 1 {
-		log.Printf("no errors of type %s found", g.typeName)
-		return
+	base := Generator{
+		compatIs:    *flagIs,
+		makePub:     *flagPub,
+		specSuffix:  *flagSuffix,
+		imports:     imports,
+		stack:       *flagStack,
+		codeMatches: *flagCodeM,
+		registry:    *flagReg,
+		suppressed:  *flagSupp,
+		immutable:   *flagImmut,
+		wrapped:     *flagWrapped,
+		outPkg:      *flagPkg,
+		outDir:      outDir,
+		fmtModes:    *flagFmtModes,
+		reqIDKey:    *flagReqID,
+		fast:        *flagFast,
+		testHelpers: *flagTestHelp,
+		causeFirst:  *flagCauseFirst,
+		cliMethod:   *flagCLI,
+		cliColor:    *flagCLIColor,
+		goString:    *flagGoString,
+		ctorMap:     *flagCtorMap,
+		wrapVerb:    *flagWrapVerb,
+		logOnce:     *flagLogOnce,
+		isFunc:      *flagIsFunc,
+		sepComment:  *flagSepComm,
+		goimports:   *flagGoimp,
+		metadata:    *flagMeta,
+		wrapType:    *flagWrapType,
+		cacheMsg:    *flagCacheMsg,
+		asHelpers:   *flagAsHelpers,
+		buildTags:   *flagBuildTags,
+		goos:        *flagGOOS,
+		goarch:      *flagGOARCH,
+		buildCons:   *flagBuildCons,
+		text:        *flagText,
+		matchFields: *flagMatchFields,
+		retIface:    *flagRetIface,
+		codeEnum:    *flagCodeEnum,
+		codeBase:    *flagCodeBase,
+		short:       *flagShort,
+		noIs:        *flagNoIs,
+		stringer:    *flagStringer,
+		wrapParam:   *flagWrapParam,
+		iface:       *flagInterface,
+		domain:      *flagDomain,
+		causer:      *flagCauser,
+		sentinels:   *flagSentinels,
+		equal:       *flagEqual,
+		options:     *flagOptions,
+		renameRes:   *flagRenameRes,
+		nolint:      *flagNolint,
+		named:       *flagNamed,
+		wrapCtor:    *flagWrapCtor,
+		wrapCtorSfx: *flagWrapCtorSfx,
+		minGo:       *flagMinGo,
+		structPfx:   *flagStructPfx,
+		strict:      *flagStrict,
+		context:     *flagContext,
+		stdin:       stdin,
+		verbose:     flagVerb,
+		quiet:       *flagQuiet,
 	}
 
-	g.header()
-	for _, err := range g.specs {
-		g.generate(err)
+	if len(dirs) > 1 {
+		return generatePackages(base, dirs)
 	}
 
-	src := g.format()
-
-	// Write to file.
-	outputName := *flagOut
-	if outputName == "" {
-		baseName := fmt.Sprintf("%s_def.go", g.typeName)
-		outputName = filepath.Join(dir, strings.ToLower(baseName))
+	regenerate := func() ([]string, int, error) {
+		return generateAll(base, args, dir)
 	}
-	err := os.WriteFile(outputName, src, 0644)
+	outputNames, _, err := regenerate()
 	if err != nil {
-		log.Fatalf("writing output: %s", err)
+		return err
 	}
-}
-
-func isDirectory(s string) bool {
-	stat, err := os.Stat(s)
-	if err != nil {
-		log.Fatal(err)
+	if *flagWatch {
+		watch(dir, args, outputNames, regenerate)
 	}
-	return stat.IsDir()
+	return nil
 }
 
-type Generator struct {
-	typeName   string
-	compatIs   bool
-	makePub    bool
-	specSuffix string
-	imports    []string
-	buf        bytes.Buffer
-	specs      []ErrorSpec
-	pkgName    string
-}
-
-// ErrorSpec represents an error to be generated. The two fields correspond to the constant
-// declaration name and the template in the associated string value.
-type ErrorSpec struct{ name, template string }
-
-// loadPackage loads the (expected) single package given a pattern and inspects
-// the source code files to collect error definitions.
-func (g *Generator) loadPackage(pattern []string) {
-	cfg := &packages.Config{
-		Mode:  packages.NeedSyntax,
-		Tests: false,
-	}
-	pkgs, err := packages.Load(cfg, pattern...)
-	if err != nil {
-		log.Fatal(err)
+// generatePackages generates the errors of each of the package directories, with at most
+// GOMAXPROCS packages at a time. A failure in a package does not stop the others, the errors of
+// all the failed packages are returned once every package is done.
+func generatePackages(base Generator, dirs []string) error {
+	errs := make([]error, len(dirs))
+	sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+	var wg sync.WaitGroup
+	for i, dir := range dirs {
+		wg.Add(1)
+		go func(i int, dir string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			g := base
+			g.outDir = dir
+			if _, _, err := generateAll(g, []string{dir}, dir); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", dir, err)
+			}
+		}(i, dir)
 	}
-	if len(pkgs) != 1 {
-		log.Fatalf("too many packages: found %d, expected 1", len(pkgs))
+	wg.Wait()
+
+	var failures []string
+	for _, err := range errs {
+		if err != nil {
+			failures = append(failures, err.Error())
+		}
 	}
-	pkg := pkgs[0]
-	for _, file := range pkg.Syntax {
-		g.processFile(file)
-		g.pkgName = file.Name.Name
-		ast.Inspect(file, g.processFile)
+	if len(failures) > 0 {
+		return fmt.Errorf("generating %d of %d packages failed:\n%s",
+			len(failures), len(dirs), strings.Join(failures, "\n"))
 	}
+	return nil
 }
 
-// Printf is an utility to append data to the internal buffer.
-func (g *Generator) Printf(fmtStr string, args ...interface{}) {
-	fmt.Fprintf(&g.buf, fmtStr, args...)
+// stdoutMu serializes the writes of generated code to stdout, when generating several packages.
+var stdoutMu sync.Mutex
+
+// writeStdout writes generated code to stdout.
+func writeStdout(src []byte) error {
+	stdoutMu.Lock()
+	defer stdoutMu.Unlock()
+	_, err := os.Stdout.Write(src)
+	return err
 }
 
-// processFile is called by ast.Inspect and take care of collecting the error definitions.
-func (g *Generator) processFile(node ast.Node) bool {
-	decl, ok := node.(*ast.GenDecl)
-	if !ok || decl.Tok != token.CONST {
-		return true
+// generateAll generates and writes the errors of all the types given with -type, returning the
+// names of the output files and the number of generated errors.
+func generateAll(base Generator, args []string, dir string) (outputNames []string, nspecs int, err error) {
+	base.ifacePkg = base.ifacePackage(dir)
+	var gens []*Generator
+	for _, typeName := range strings.Split(*flagTyp, ",") {
+		g := base
+		g.typeName = strings.TrimSpace(typeName)
+		if err := g.loadPackage(args); err != nil {
+			return nil, 0, err
+		}
+		if len(g.specs) < 1 {
+			g.logf("no errors of type %s found", g.typeName)
+			continue
+		}
+		g.sortSpecs()
+		gens = append(gens, &g)
 	}
-	for _, spec := range decl.Specs {
-		vspec := spec.(*ast.ValueSpec) // Guaranteed to succeed as this is CONST.
-		var typ string
-		if vspec.Type == nil {
-			ce, ok := vspec.Values[0].(*ast.CallExpr)
-			if !ok {
-				continue
-			}
-			f, ok := ce.Fun.(*ast.Ident)
-			if !ok {
-				continue
-			}
-			typ = f.Name
-		} else {
-			ident, ok := vspec.Type.(*ast.Ident)
-			if !ok {
-				continue
+	if len(gens) < 1 {
+		if *flagSummary || base.verbose {
+			base.logf("summary: generated 0 errors of type %s", *flagTyp)
+		}
+		return nil, 0, nil
+	}
+	if err := checkDuplicates(gens); err != nil {
+		return nil, 0, err
+	}
+	if err := checkReplacements(gens); err != nil {
+		return nil, 0, err
+	}
+	for _, g := range gens {
+		if err := g.checkGoVersion(); err != nil {
+			return nil, 0, err
+		}
+		if err := g.checkSpecs(); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	var srcs [][]byte
+	if *flagSplit {
+		for _, g := range gens {
+			outputNames = append(outputNames, outputName("", dir, g.typeName, *flagOutSuffix))
+		}
+		outputNames = append(outputNames, filepath.Join(dir, "gorror_common.go"))
+		common := base
+		var err error
+		if srcs, err = generateSplit(&common, gens); err != nil {
+			return nil, 0, err
+		}
+	} else {
+		outputNames = append(outputNames, outputName(*flagOut, dir, gens[0].typeName, *flagOutSuffix))
+		file := base
+		src, err := generateFile(&file, gens)
+		if err != nil {
+			return nil, 0, err
+		}
+		srcs = append(srcs, src)
+	}
+
+	if *flagCoverage {
+		for _, g := range gens {
+			if err := g.checkCoverage(); err != nil {
+				return nil, 0, err
 			}
-			typ = ident.Name
 		}
-		if typ != g.typeName {
+	}
+
+	for i, src := range srcs {
+		if base.stdin != nil {
+			if err := writeStdout(src); err != nil {
+				return nil, 0, fmt.Errorf("writing output: %w", err)
+			}
 			continue
 		}
-		name := vspec.Names[0].Name
-		var template string
-		switch v := vspec.Values[0].(type) {
-		case *ast.CallExpr:
-			s, ok := v.Args[0].(*ast.BasicLit)
-			if !ok || s.Kind != token.STRING {
-				log.Fatalf("expected string literal, got %#v\n", v.Args[0])
+		if *flagInplace {
+			var err error
+			if src, err = inplaceSource(outputNames[i], src); err != nil {
+				return nil, 0, fmt.Errorf("writing %s in place: %w", outputNames[i], err)
 			}
-			template = s.Value
-		case *ast.BasicLit:
-			if v.Kind != token.STRING {
-				log.Fatalf("expected string literal or cast to %s, got %#v\n", typ, v)
+		}
+		if *flagDryRun {
+			// Print to stdout instead of writing to file.
+			if err := writeStdout(src); err != nil {
+				return nil, 0, fmt.Errorf("writing output: %w", err)
 			}
-			template = v.Value
-		default:
-			log.Fatalf("expected string literal or cast to %s, got %#v\n", typ, v)
+			base.logf("dry run: not writing %s", outputNames[i])
+			continue
 		}
-		template, err := strconv.Unquote(template)
-		if err != nil {
-			log.Fatal(err)
+		// Write to file.
+		if err := os.WriteFile(outputNames[i], src, 0644); err != nil {
+			return nil, 0, fmt.Errorf("writing output: %w", err)
 		}
-		g.specs = append(g.specs, ErrorSpec{name, template})
 	}
-	return false
+	if *flagDryRun {
+		for _, g := range gens {
+			g.logf("dry run: generated %d errors of type %s", len(g.generated), g.typeName)
+		}
+	}
+	for _, g := range gens {
+		nspecs += len(g.generated)
+	}
+	if *flagSummary || base.verbose {
+		if base.stdin != nil {
+			outputNames = []string{"stdout"}
+		}
+		base.logf("%s", summary(gens, outputNames))
+	}
+	return outputNames, nspecs, nil
 }
 
-// header generates the package header, imports and common types.
-func (g *Generator) header() {
-	// Generate header and package declaration.
-	g.Printf("// Errors generated by Gorror; DO NOT EDIT.\n\npackage %s\n\n", g.pkgName)
-	// Generate import statements.
-	imports := append(g.imports, "fmt", "errors")
-	sort.Strings(imports)
-	g.Printf("import (\n")
-	for _, imp := range imports {
-		g.Printf("\t%q\n", imp)
+// summary returns a one-line summary of the errors of the given generators: their number, how
+// many have fields, how many use each wrap mode and the output files.
+func summary(gens []*Generator, outputNames []string) string {
+	var types []string
+	var total, withFields int
+	modes := make(map[WrapMode]int)
+	for _, g := range gens {
+		types = append(types, g.typeName)
+		for _, spec := range g.specs {
+			t := spec.parsed
+			total++
+			if len(t.roots) > 0 {
+				withFields++
+			}
+			modes[t.wrap]++
+		}
 	}
-	g.Printf(")\n\n")
-	// Generate _errWrap structure.
-	g.Printf("type _errWrap struct{ cause error }\n")
-	g.Printf("func (w *_errWrap) Unwrap() error { return w.cause }\n\n")
+	return fmt.Sprintf("summary: generated %d errors of type %s, %d with fields "+
+		"(optwrap %d, wrap %d, nowrap %d, multiwrap %d) to %s",
+		total, strings.Join(types, ", "), withFields,
+		modes[OptWrap], modes[MustWrap], modes[NoWrap], modes[MultiWrap], strings.Join(outputNames, ", "))
+}
 
-	if g.compatIs {
-		g.Printf("func (%s) Error() string { panic(\"Should not be called\") }\n\n", g.typeName)
-	} else {
-		g.Printf(`func (e %[1]s) IsIn(err error) bool {
-	var ei interface { Is(%[1]s) bool; Unwrap() error }
-	if errors.As(err, &ei) {
-		if ei.Is(e) { return true }
-		return e.IsIn(ei.Unwrap())
-	}
-	return false}`, g.typeName)
-		g.Printf("\n\n")
+// checkFiles checks that the files given as arguments form a single package: they have to be
+// in the same directory and declare the same package name.
+func checkFiles(files []string) error {
+	var dir, pkgName string
+	for i, file := range files {
+		f, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.PackageClauseOnly)
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			dir, pkgName = filepath.Dir(file), f.Name.Name
+			continue
+		}
+		if d := filepath.Dir(file); d != dir {
+			return fmt.Errorf("files %s and %s are in different directories, expected a single package",
+				files[0], file)
+		}
+		if f.Name.Name != pkgName {
+			return fmt.Errorf("files %s (package %s) and %s (package %s) are in different packages, expected a single one",
+				files[0], pkgName, file, f.Name.Name)
+		}
 	}
+	return nil
 }
 
-// generate generates the code for a single error implementations.
-func (g *Generator) generate(spec ErrorSpec) {
-	structName := g.structName(spec.name)
-	template := parseTemplate(spec.template)
-
-	// Generate structure for error.
-	g.Printf("type %s struct {\n", structName)
-	if template.wrap != NoWrap {
-		g.Printf("\t_errWrap\n")
+// isDirectory reports whether s is a directory. A missing file, e.g. removed by an editor
+// while saving, is not a directory.
+func isDirectory(s string) (bool, error) {
+	stat, err := os.Stat(s)
+	if os.IsNotExist(err) {
+		return false, nil
 	}
-	for _, f := range template.fields {
-		g.Printf("\t%s %s\n", f.name, f.typ)
+	if err != nil {
+		return false, err
 	}
-	g.Printf("}\n\n")
+	return stat.IsDir(), nil
+}
 
-	// Generate constructor with all arguments.
-	constPrefix := "new"
-	if g.makePub {
-		constPrefix = "New"
-	}
-	g.Printf("func %s%s(", constPrefix, strings.Title(structName))
-	for i, f := range template.fields {
-		g.Printf("%s %s", f.name, f.typ)
-		if i < len(template.fields)-1 {
-			g.Printf(", ")
-		}
+type Generator struct {
+	typeName    string
+	compatIs    bool
+	makePub     bool
+	specSuffix  string
+	imports     []string
+	stack       bool
+	codeMatches bool
+	registry    bool
+	suppressed  bool
+	immutable   bool
+	wrapped     bool
+	outPkg      string // overrides the package name of the generated file
+	outDir      string // directory of the generated files
+	fmtModes    bool
+	reqIDKey    string // context key of the request ID, if enabled
+	fast        bool   // build messages with a strings.Builder instead of fmt.Sprintf
+	testHelpers bool
+	causeFirst  bool // take the cause before the fields in constructors
+	cliMethod   bool
+	cliColor    string // ANSI SGR parameters of the CLIString message, empty for no colors
+	goString    bool
+	ctorMap     bool
+	wrapVerb    string // verb formatting the cause appended to messages, %v if empty
+	logOnce     bool
+	isFunc      bool
+	sepComment  bool // emit a divider comment before each error
+	goimports   bool // format with goimports instead of go/format
+	metadata    bool
+	wrapType    string // name of the type holding the cause, _errWrap if empty
+	cacheMsg    bool   // compute the message once, in constructors and Wrap
+	asHelpers   bool   // generate AsX functions extracting errors from chains
+	buildTags   string // comma-separated build tags used when loading the package
+	goos        string // GOOS used when loading the package, if not the host one
+	goarch      string // GOARCH used when loading the package, if not the host one
+	buildCons   string // build constraint of the generated file, if any
+	text        bool   // implement encoding.TextMarshaler
+	matchFields bool   // make Is match errors of the same kind with equal fields
+	retIface    bool   // make constructors return interfaces instead of pointers
+	codeEnum    bool   // generate an enum with a code per error
+	codeBase    int    // first code assigned to errors without a code: directive
+	short       bool   // append only the own message of the immediate cause in Error
+	noIs        bool   // omit the Is methods and the IsIn method of the type
+	stringer    bool   // generate String methods, implementing fmt.Stringer
+	wrapParam   string // name of the cause parameter of the constructors of wrap: errors
+	iface       string // interface implemented by the errors, possibly qualified by its import path
+	ifacePkg    string // name of the package of iface, if qualified by a loaded import path
+	domain      string // value returned by Domain methods, if any
+	causer      bool   // generate Cause methods, implementing the causer of github.com/pkg/errors
+	sentinels   bool   // generate shared instances of the nowrap: errors without fields
+	equal       bool   // generate Equal methods comparing errors by value
+	options     bool   // set optional fields with functional options instead of parameters
+	renameRes   bool   // rename fields named after Go keywords instead of failing
+	nolint      string // comma-separated linters disabled on the generated files, if any
+	named       bool   // resolve {{.name}} placeholders with the declarations of gorror-fields: comments
+	wrapCtor    bool   // generate constructors also taking the cause for OptWrap errors
+	wrapCtorSfx string // suffix of the names of the constructors taking the cause
+	minGo       string // oldest Go version supported by the generated files, if given
+	structPfx   string // prefix of the names of the generated types
+	strict      bool   // fail on messages whose verbs do not match their fields
+	context     bool   // carry key/value context, appended to messages
+	stdin       []byte // source read from stdin, if any, loaded instead of the package
+	verbose     bool
+	quiet       bool
+	buf         bytes.Buffer
+	specs       []ErrorSpec
+	generated   []string // names of the generated specs
+	skipped     []SkippedSpec
+	pkgName     string
+}
+
+// SkippedSpec represents a constant of the error type for which no error is generated.
+type SkippedSpec struct{ name, reason string }
+
+// ErrorSpec represents an error to be generated. The fields correspond to the constant
+// declaration name, the template in the associated string value, the doc comment and the
+// template as parsed when loading the package.
+type ErrorSpec struct {
+	name, template, doc string
+	parsed              ParsedTemplate
+}
+
+// loadPackage loads the (expected) single package given a pattern, or the file read from
+// stdin, and inspects the source code files to collect error definitions.
+func (g *Generator) loadPackage(pattern []string) error {
+	cfg := &packages.Config{
+		Mode:  packages.NeedName | packages.NeedSyntax,
+		Tests: false,
+		Fset:  token.NewFileSet(),
 	}
-	if template.wrap == MustWrap {
-		if len(template.fields) > 0 {
-			g.Printf(", ")
-		}
-		g.Printf("err error")
+	if g.buildTags != "" {
+		cfg.BuildFlags = []string{"-tags=" + g.buildTags}
 	}
-	g.Printf(") *%[1]s {\n\treturn &%[1]s{", structName)
-	if template.wrap == MustWrap || template.wrap == OptWrap {
-		ew := "_errWrap{nil}"
-		if template.wrap == MustWrap {
-			ew = "_errWrap{err}"
+	if g.goos != "" || g.goarch != "" {
+		cfg.Env = os.Environ()
+		if g.goos != "" {
+			cfg.Env = append(cfg.Env, "GOOS="+g.goos)
 		}
-		g.Printf(ew)
-		if len(template.fields) > 0 {
-			g.Printf(", ")
+		if g.goarch != "" {
+			cfg.Env = append(cfg.Env, "GOARCH="+g.goarch)
 		}
 	}
-	for i, f := range template.fields {
-		g.Printf("%s", f.name)
-		if i < len(template.fields)-1 {
-			g.Printf(", ")
+	if g.stdin != nil {
+		file, err := parser.ParseFile(cfg.Fset, "<stdin>", g.stdin, parser.ParseComments)
+		if err != nil {
+			return err
 		}
+		return g.inspectFile(cfg.Fset, file)
 	}
-	g.Printf("}\n}\n\n")
-
-	// Generate Error method.
-	g.Printf("func (e *%s) Error() string {\n", structName)
-	switch template.wrap {
-	case OptWrap:
-		g.Printf("\tif e.cause == nil {\n\t\treturn fmt.Sprintf(\"%v\"", template.fmt)
-		// Add call to Sprintf w/o cause.
-		for _, f := range template.fields {
-			g.Printf(", e.%s", f.val)
+	pkgs, err := packages.Load(cfg, pattern...)
+	if err != nil {
+		return err
+	}
+	if len(pkgs) != 1 {
+		var names []string
+		for _, pkg := range pkgs {
+			names = append(names, pkg.PkgPath)
 		}
-		g.Printf(")\n\t}\n\treturn fmt.Sprintf(\"%s: %%v\", ", template.fmt)
-		// Add params to Sprintf w/ cause.
-		for _, f := range template.fields {
-			g.Printf("e.%s, ", f.val)
+		return fmt.Errorf("too many packages: found %d (%s), expected 1", len(pkgs), strings.Join(names, ", "))
+	}
+	for _, file := range pkgs[0].Syntax {
+		if err := g.inspectFile(cfg.Fset, file); err != nil {
+			return err
 		}
-		g.Printf("e.cause)\n")
-	case NoWrap:
-		g.Printf("\treturn fmt.Sprintf(\"%v\"", template.fmt)
-		for _, f := range template.fields {
-			g.Printf(", e.%s", f.val)
+	}
+	tpkg := typesOf(cfg.Fset, pkgs[0])
+	if tpkg.Scope().Lookup(g.typeName) == nil && pkgs[0].PkgPath == "command-line-arguments" {
+		// The files given as arguments can use a type declared in another file of their package.
+		dir := filepath.Dir(pattern[0])
+		if !filepath.IsAbs(dir) {
+			// Not to be taken for an import path.
+			dir = "./" + filepath.ToSlash(dir)
 		}
-		g.Printf(")\n")
-	case MustWrap:
-		g.Printf("\treturn fmt.Sprintf(\"%s: %%v\", ", template.fmt)
-		// Add params to Sprintf w/ cause.
-		for _, f := range template.fields {
-			g.Printf("e.%s, ", f.val)
+		all, err := packages.Load(cfg, dir)
+		if err != nil || len(all) != 1 || len(all[0].Syntax) == 0 {
+			g.verbosef("not checking type %s: package of %s not loaded", g.typeName, pattern[0])
+			return nil
 		}
-		g.Printf("e.cause)\n")
+		tpkg = typesOf(cfg.Fset, all[0])
 	}
-	g.Printf("}\n")
+	return checkType(tpkg, g.typeName, len(g.specs))
+}
 
-	if template.wrap != NoWrap {
-		// Generate Wrap method.
-		g.Printf(`
-func (e *%s) Wrap(cause error) error {
-	e.cause = cause
-	return e
+// typesOf type-checks the syntax of a package, only to tell the declared types apart: imports are
+// not resolved and the errors are ignored. The package may not compile anyway, as it usually
+// refers to the code yet to be generated.
+func typesOf(fset *token.FileSet, pkg *packages.Package) *types.Package {
+	conf := types.Config{
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			return nil, fmt.Errorf("%s not imported", path)
+		}),
+		Error: func(error) {},
+		Sizes: types.SizesFor("gc", runtime.GOARCH),
+	}
+	tpkg, _ := conf.Check(pkg.PkgPath, fset, pkg.Syntax, nil)
+	return tpkg
 }
-`, structName)
+
+// importerFunc implements types.Importer with a function.
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
+
+// checkType checks that the type given with -type is declared in the package as a string type,
+// or as an integer type whose constants carry their templates in //gorror: comments, given the
+// number of errors found.
+func checkType(pkg *types.Package, typeName string, found int) error {
+	obj, ok := pkg.Scope().Lookup(typeName).(*types.TypeName)
+	if !ok {
+		return fmt.Errorf("type %s is not declared in package %s", typeName, pkg.Name())
+	}
+	basic, ok := obj.Type().Underlying().(*types.Basic)
+	switch {
+	case ok && basic.Kind() == types.Invalid:
+		// Declared with a type of an imported package, which is not resolved.
+		return nil
+	case ok && basic.Info()&types.IsString != 0:
+		return nil
+	case ok && basic.Info()&types.IsInteger != 0 && found > 0:
+		return nil
+	case ok && basic.Info()&types.IsInteger != 0:
+		return fmt.Errorf("type %s is an integer type, but none of its constants has a //gorror: comment", typeName)
 	}
+	return fmt.Errorf("type %s is not a string type: its underlying type is %s", typeName, obj.Type().Underlying())
+}
 
-	// Generate Is method.
-	if g.compatIs {
-		g.Printf("\nfunc (*%s) Is(e error) bool { return e == %s }\n\n", structName, spec.name)
-	} else {
-		g.Printf("\nfunc (*%s) Is(e %s) bool { return e == %s }\n\n", structName, g.typeName, spec.name)
+// inspectFile collects the error definitions of a source file.
+func (g *Generator) inspectFile(fset *token.FileSet, file *ast.File) error {
+	g.pkgName = file.Name.Name
+	if !isGenerated(file) && !hasRegion(file) && declares(file, g.wrapTypeName()) {
+		g.logf("warning: %s is already declared in package %s, choose another name with -wrap-type",
+			g.wrapTypeName(), g.pkgName)
+	}
+	consts, found := 0, len(g.specs)
+	var err error
+	ast.Inspect(file, func(node ast.Node) bool {
+		if err != nil {
+			return false
+		}
+		if decl, ok := node.(*ast.GenDecl); ok && decl.Tok == token.CONST {
+			consts++
+		}
+		var more bool
+		more, err = g.processFile(node)
+		return more
+	})
+	if err != nil {
+		return err
 	}
+	g.verbosef("%s: %d const declarations, %d errors of type %s",
+		fset.File(file.Pos()).Name(), consts, len(g.specs)-found, g.typeName)
+	return nil
 }
 
-func (g *Generator) structName(specName string) string {
-	var b strings.Builder
-	runes := []rune(specName)
-	if g.makePub {
-		b.WriteRune(unicode.ToUpper(runes[0]))
-	} else {
-		b.WriteRune(unicode.ToLower(runes[0]))
+// isGenerated reports whether the file was generated by Gorror.
+func isGenerated(file *ast.File) bool {
+	// The header may come after a build constraint.
+	for _, group := range file.Comments {
+		if group.Pos() > file.Package {
+			break
+		}
+		for _, c := range group.List {
+			if c.Text == generatedHeader || c.Text == legacyHeader {
+				return true
+			}
+		}
 	}
-	rest := string(runes[1:])
-	if len(g.specSuffix) > 0 {
-		rest = strings.TrimSuffix(rest, g.specSuffix)
+	return false
+}
+
+// declares reports whether the file declares the given name at package level.
+func declares(file *ast.File, name string) bool {
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil && decl.Name.Name == name {
+				return true
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if spec.Name.Name == name {
+						return true
+					}
+				case *ast.ValueSpec:
+					for _, ident := range spec.Names {
+						if ident.Name == name {
+							return true
+						}
+					}
+				}
+			}
+		}
 	}
-	b.WriteString(rest)
-	return b.String()
+	return false
 }
 
-type ParsedTemplate struct {
-	wrap   WrapMode
-	fields []Field
-	fmt    string
+// sortSpecs sorts the collected specifications by constant name, so that the generated
+// output does not depend on the order in which files and declarations are visited.
+func (g *Generator) sortSpecs() {
+	sort.Slice(g.specs, func(i, j int) bool { return g.specs[i].name < g.specs[j].name })
 }
 
-type WrapMode int
+// logf logs a message unless quiet mode is enabled.
+func (g *Generator) logf(format string, args ...interface{}) {
+	if !g.quiet {
+		log.Printf(format, args...)
+	}
+}
 
-const (
+// verbosef logs a message when verbose mode is enabled, unless quiet mode is enabled.
+func (g *Generator) verbosef(format string, args ...interface{}) {
+	if g.verbose && !g.quiet {
+		log.Printf(format, args...)
+	}
+}
+
+// printDoc appends a doc comment to the internal buffer, if not empty.
+func (g *Generator) printDoc(doc string) {
+	if doc == "" {
+		return
+	}
+	for _, line := range strings.Split(doc, "\n") {
+		if line == "" {
+			g.Printf("//\n")
+		} else {
+			g.Printf("// %s\n", line)
+		}
+	}
+}
+
+// Printf is an utility to append data to the internal buffer.
+func (g *Generator) Printf(fmtStr string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, fmtStr, args...)
+}
+
+// processFile is called by ast.Inspect and take care of collecting the error definitions.
+//
+// The template of an error is the string value of the constant, either a string literal or a
+// cast of a string literal. Constants whose value is not a string (e.g. an int enum using iota)
+// can instead carry the template in a //gorror: comment. When both are present the string
+// value takes precedence and the comment is ignored.
+func (g *Generator) processFile(node ast.Node) (bool, error) {
+	decl, ok := node.(*ast.GenDecl)
+	if !ok || decl.Tok != token.CONST {
+		return true, nil
+	}
+	var lastTyp string
+	grouped := false // whether the block has constants of the type
+	for _, spec := range decl.Specs {
+		vspec := spec.(*ast.ValueSpec) // Guaranteed to succeed as this is CONST.
+		typ := specType(vspec, lastTyp)
+		lastTyp = typ
+		if typ == "" && grouped && isStringLit(vspec) {
+			// Unlike constants without a value, ErrB = "b" does not take the type of the previous
+			// constants: it is an untyped string, which is likely a mistake.
+			g.logf("warning: %s is an untyped string constant, not of type %s; declare it as %s %s = ...",
+				vspec.Names[0].Name, g.typeName, vspec.Names[0].Name, g.typeName)
+		}
+		if typ != g.typeName {
+			for _, ident := range vspec.Names {
+				g.verbosef("skip %s: %s", ident.Name, typeReason(typ))
+			}
+			continue
+		}
+		grouped = true
+		name := vspec.Names[0].Name
+		doc := vspec.Doc
+		if !decl.Lparen.IsValid() {
+			// The doc comment of an ungrouped declaration is attached to the GenDecl.
+			doc = decl.Doc
+		}
+		template, ok, err := stringValue(vspec)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", name, err)
+		}
+		if !ok {
+			var err error
+			template, ok, err = commentTemplate(doc, vspec.Comment)
+			if err != nil {
+				return false, err
+			}
+			if !ok {
+				g.skipped = append(g.skipped, SkippedSpec{name, "no string value nor //gorror: comment"})
+				g.verbosef("skip %s: no string value nor //gorror: comment", name)
+				continue
+			}
+		}
+		if g.named {
+			decls, _ := fieldsComment(doc, vspec.Comment)
+			var err error
+			if template, err = resolveNamed(template, decls); err != nil {
+				return false, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		if g.renameRes {
+			template = renameReserved(template)
+		}
+		parsed, err := parseTemplate(template)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", name, err)
+		}
+		if err := parsed.checkVerbs(); err != nil {
+			if g.strict {
+				return false, fmt.Errorf("%s: %w", name, err)
+			}
+			g.logf("warning: %s: %s", name, err)
+		}
+		g.verbosef("found %s: %s", name, parsed)
+		g.specs = append(g.specs, ErrorSpec{name, template, docText(doc), parsed})
+	}
+	return false, nil
+}
+
+// specType returns the name of the type of a constant specification. Specifications without
+// type and values implicitly repeat the previous one, whose type is given as lastTyp.
+func specType(vspec *ast.ValueSpec, lastTyp string) string {
+	switch {
+	case vspec.Type != nil:
+		if ident, ok := vspec.Type.(*ast.Ident); ok {
+			return ident.Name
+		}
+	case len(vspec.Values) == 0:
+		return lastTyp
+	default:
+		if ce, ok := vspec.Values[0].(*ast.CallExpr); ok {
+			if f, ok := ce.Fun.(*ast.Ident); ok {
+				return f.Name
+			}
+		}
+	}
+	return ""
+}
+
+// typeReason describes why a constant of the given type, as returned by specType, is skipped.
+func typeReason(typ string) string {
+	if typ == "" {
+		return "untyped or of an unknown type"
+	}
+	return "of type " + typ
+}
+
+// isStringLit reports whether the value of a constant specification is a string literal.
+func isStringLit(vspec *ast.ValueSpec) bool {
+	if len(vspec.Values) == 0 {
+		return false
+	}
+	lit, ok := vspec.Values[0].(*ast.BasicLit)
+	return ok && lit.Kind == token.STRING
+}
+
+// stringValue returns the unquoted value of a constant specification when it is a string
+// literal or a cast of a string literal.
+func stringValue(vspec *ast.ValueSpec) (string, bool, error) {
+	if len(vspec.Values) == 0 {
+		return "", false, nil
+	}
+	value := vspec.Values[0]
+	if ce, ok := value.(*ast.CallExpr); ok && len(ce.Args) == 1 {
+		value = ce.Args[0]
+	}
+	lit, ok := value.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false, nil
+	}
+	s, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", false, err
+	}
+	return s, true, nil
+}
+
+// docText returns the text of a doc comment without comment markers and directives.
+func docText(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+	var lines []string
+	for _, c := range doc.List {
+		text := c.Text
+		if strings.HasPrefix(text, "/*") {
+			text = strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/")
+			lines = append(lines, strings.Split(strings.TrimSpace(text), "\n")...)
+			continue
+		}
+		text = strings.TrimPrefix(text, "//")
+		if strings.HasPrefix(text, "gorror:") || strings.HasPrefix(text, "go:") ||
+			strings.HasPrefix(strings.TrimSpace(text), fieldsDirective) {
+			continue
+		}
+		lines = append(lines, strings.TrimPrefix(text, " "))
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// commentTemplate looks for a //gorror: directive in the comment groups and returns its
+// template. The template is either the quoted string following the directive or, when not
+// quoted, the rest of the comment line.
+func commentTemplate(groups ...*ast.CommentGroup) (string, bool, error) {
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+		for _, c := range group.List {
+			if !strings.HasPrefix(c.Text, "//gorror:") {
+				continue
+			}
+			text := strings.TrimSpace(strings.TrimPrefix(c.Text, "//gorror:"))
+			if !strings.HasPrefix(text, `"`) && !strings.HasPrefix(text, "`") {
+				return text, true, nil
+			}
+			s, err := strconv.Unquote(text)
+			if err != nil {
+				return "", false, fmt.Errorf("invalid directive %s: %w", c.Text, err)
+			}
+			return s, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// fieldsDirective starts the comment declaring the fields of a template, with -named.
+const fieldsDirective = "gorror-fields:"
+
+// fieldsComment looks for a gorror-fields: comment in the comment groups and returns the
+// field declarations following it.
+func fieldsComment(groups ...*ast.CommentGroup) (string, bool) {
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+		for _, c := range group.List {
+			text := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+			if strings.HasPrefix(text, fieldsDirective) {
+				return strings.TrimPrefix(text, fieldsDirective), true
+			}
+		}
+	}
+	return "", false
+}
+
+// namedRE matches a placeholder referencing a field by name, with -named.
+var namedRE = regexp.MustCompile(`{{\.([\pL\p{Nd}_]+)}}`)
+
+// resolveNamed replaces the {{.name}} placeholders of a template with inline ones, given the
+// comma-separated field declarations, each the content of an inline placeholder: e.g.
+// {{.file}} becomes {{file string %q}} with the declaration "file string %q". All the
+// declared fields have to be referenced.
+func resolveNamed(template, decls string) (string, error) {
+	placeholders := make(map[string]string)
+	var names []string
+	for _, decl := range strings.Split(decls, ",") {
+		decl = strings.Join(strings.Fields(decl), " ")
+		if decl == "" {
+			continue
+		}
+		placeholder := "{{" + decl + "}}"
+		if loc := tmplRE.FindStringIndex(placeholder); loc == nil || loc[0] != 0 || loc[1] != len(placeholder) {
+			return "", fmt.Errorf("invalid field declaration %q, expected name type verb", decl)
+		}
+		name := decl[:strings.IndexByte(decl, ' ')]
+		if _, ok := placeholders[name]; ok {
+			return "", fmt.Errorf("field %s is declared more than once", name)
+		}
+		placeholders[name] = placeholder
+		names = append(names, name)
+	}
+	used := make(map[string]bool)
+	var err error
+	resolved := namedRE.ReplaceAllStringFunc(template, func(s string) string {
+		name := namedRE.FindStringSubmatch(s)[1]
+		placeholder, ok := placeholders[name]
+		if !ok && err == nil {
+			err = fmt.Errorf("field %s of template %q is not declared in a %s comment", name, template, fieldsDirective)
+		}
+		used[name] = true
+		return placeholder
+	})
+	if err != nil {
+		return "", err
+	}
+	for _, name := range names {
+		if !used[name] {
+			return "", fmt.Errorf("field %s is declared but not used in template %q", name, template)
+		}
+	}
+	return resolved, nil
+}
+
+// header generates the package header, imports and common types.
+func (g *Generator) header() error {
+	if err := g.fileHeader(g.importList()); err != nil {
+		return err
+	}
+	g.importRefs()
+	g.commonDecls()
+	g.typeDecls()
+	return nil
+}
+
+// packageName returns the package name of the generated file: the one given with -pkg, the one
+// of the source package or the base name of the output directory, in this order.
+func (g *Generator) packageName() (string, error) {
+	switch {
+	case g.outPkg != "":
+		return g.outPkg, nil
+	case g.pkgName != "":
+		return g.pkgName, nil
+	}
+	if name := filepath.Base(g.outDir); token.IsIdentifier(name) {
+		return name, nil
+	}
+	return "", errors.New("cannot determine the package name of the generated code, use -pkg")
+}
+
+// importList returns the imports needed by the errors of the type.
+func (g *Generator) importList() []string {
+	imports := append([]string{"fmt"}, g.imports...)
+	equalCauses := g.equal && (g.hasWrapMode(OptWrap) || g.hasWrapMode(MustWrap) || g.hasWrapMode(MultiWrap))
+	if (!g.compatIs && !g.noIs) || g.isFunc || g.asHelpers || equalCauses {
+		imports = append(imports, "errors")
+	}
+	if g.stack {
+		imports = append(imports, "runtime")
+	}
+	if g.reqIDKey != "" {
+		imports = append(imports, "context")
+	}
+	if g.logOnce {
+		imports = append(imports, "sync/atomic")
+	}
+	if g.fast {
+		imports = append(imports, "strconv", "strings")
+	}
+	if g.hasPattern() {
+		imports = append(imports, "regexp")
+	}
+	if g.equal && g.hasDeepEqual() {
+		imports = append(imports, "reflect")
+	}
+	if i := strings.LastIndexByte(g.iface, '.'); i > 0 && !hasImport(imports, g.iface[:i]) {
+		imports = append(imports, g.iface[:i])
+	}
+	return append(imports, g.fieldImports(imports)...)
+}
+
+// commonImports returns the imports needed by the common declarations.
+func (g *Generator) commonImports() []string {
+	var imports []string
+	if g.stack {
+		imports = append(imports, "runtime")
+	}
+	if g.testHelpers && g.compatIs {
+		imports = append(imports, "errors")
+	}
+	if g.context {
+		imports = append(imports, "fmt", "sort")
+	}
+	return imports
+}
+
+// fileHeader generates the header, package declaration and import statements.
+func (g *Generator) fileHeader(imports []string) error {
+	// Generate build constraint, header and package declaration.
+	if cons := g.constraint(); cons != "" {
+		g.Printf("//go:build %s\n\n", cons)
+	}
+	g.Printf("%s\n\n", generatedHeader)
+	if g.nolint != "" {
+		// Attached to the package clause, the directive applies to the whole file.
+		g.Printf("//nolint:%s\n", g.nolint)
+	}
+	pkgName, err := g.packageName()
+	if err != nil {
+		return err
+	}
+	g.Printf("package %s\n\n", pkgName)
+	if len(imports) == 0 {
+		return nil
+	}
+	// Generate import statements.
+	sort.Slice(imports, func(i, j int) bool {
+		_, pi := splitImport(imports[i])
+		_, pj := splitImport(imports[j])
+		return pi < pj
+	})
+	g.Printf("import (\n")
+	for i, imp := range imports {
+		if i > 0 && imp == imports[i-1] {
+			continue
+		}
+		if alias, p := splitImport(imp); alias != "" {
+			g.Printf("\t%s %q\n", alias, p)
+		} else {
+			g.Printf("\t%q\n", p)
+		}
+	}
+	g.Printf(")\n\n")
+	return nil
+}
+
+// constraint returns the build constraint of the generated files, combining the one given with
+// -build-constraint and the Go version given with -min-go.
+func (g *Generator) constraint() string {
+	switch {
+	case g.minGo == "":
+		return g.buildCons
+	case g.buildCons == "":
+		return g.minGo
+	}
+	return "(" + g.buildCons + ") && " + g.minGo
+}
+
+// goVersionRE matches the Go versions given with -min-go, capturing the minor version.
+var goVersionRE = regexp.MustCompile(`^go1\.(\d+)$`)
+
+// goFeature is a feature of the generated code needing a Go version newer than the first one
+// with the errors package.
+type goFeature struct {
+	name  string
+	minor int // minor version of Go 1 needed
+}
+
+// goFeatures returns the features of the generated code needing a newer Go version.
+func (g *Generator) goFeatures() []goFeature {
+	features := []goFeature{{"errors.Is and errors.As", 13}}
+	if g.logOnce {
+		// atomic.Bool.
+		features = append(features, goFeature{"-log-once", 19})
+	}
+	// Unwrap() []error is only followed by the errors package since Go 1.20.
+	if g.suppressed {
+		features = append(features, goFeature{"-suppressed", 20})
+	}
+	if g.hasWrapMode(MultiWrap) {
+		features = append(features, goFeature{"multiwrap: errors", 20})
+	}
+	return features
+}
+
+// checkGoVersion fails when a feature of the generated code needs a Go version newer than the
+// one given with -min-go.
+func (g *Generator) checkGoVersion() error {
+	if g.minGo == "" {
+		return nil
+	}
+	minor, _ := strconv.Atoi(goVersionRE.FindStringSubmatch(g.minGo)[1])
+	for _, f := range g.goFeatures() {
+		if f.minor > minor {
+			return fmt.Errorf("%s, used by the errors of type %s, needs go1.%d, newer than -min-go %s",
+				f.name, g.typeName, f.minor, g.minGo)
+		}
+	}
+	return nil
+}
+
+// checkSpecs fails on errors that cannot be generated with the given options: variadic fields
+// set with options or compared with -match-fields, fields named like the ones the generated
+// code declares, and codes given to several errors.
+func (g *Generator) checkSpecs() error {
+	for _, spec := range g.specs {
+		t := spec.parsed
+		if ps := g.ctorParams(t); len(g.optionFields(t)) > 0 && len(ps) > 0 &&
+			strings.HasPrefix(ps[len(ps)-1].typ, "...") {
+			return fmt.Errorf("%s: optional fields cannot be set with options by a variadic constructor", spec.name)
+		}
+		names := g.generatedNames(t)
+		for _, f := range t.roots {
+			if g.matchFields && !g.noIs && strings.HasPrefix(f.typ, "...") {
+				return fmt.Errorf("%s: variadic field %s cannot be compared with -match-fields", spec.name, f.name)
+			}
+			if by, ok := names[f.name]; ok {
+				return fmt.Errorf("%s: field %s clashes with the one generated for %s", spec.name, f.name, by)
+			}
+		}
+	}
+	if g.codeEnum {
+		if _, err := g.assignCodes(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// generatedNames returns the names that the generated code declares next to the fields of an
+// error, in its struct and in its constructors, with what each is generated for. The other
+// identifiers of the constructors start with an underscore, not to collide with the fields.
+func (g *Generator) generatedNames(t ParsedTemplate) map[string]string {
+	names := make(map[string]string)
+	switch {
+	case t.causeName != "":
+	case t.wrap == OptWrap || t.wrap == MustWrap:
+		names["cause"] = "the cause"
+	case t.wrap == MultiWrap:
+		names["causes"] = "the causes"
+	}
+	if len(g.optionFields(t)) > 0 {
+		names["o"], names["opts"] = "-options", "-options"
+	}
+	if g.stack {
+		names["stack"] = "-stack"
+	}
+	if g.suppressed {
+		names["suppressed"] = "-suppressed"
+	}
+	if g.reqIDKey != "" {
+		names["requestID"] = "-reqid"
+	}
+	if g.logOnce {
+		names["logged"] = "-log-once"
+	}
+	if g.metadata {
+		names["meta"] = "-metadata"
+	}
+	if g.context {
+		names["ctx"] = "-context"
+	}
+	if g.cacheMsg {
+		names["msg"] = "-cache-msg"
+	}
+	return names
+}
+
+// importRefs references the imports that fast Error methods, or the ones of errors without
+// message, may leave unused.
+func (g *Generator) importRefs() {
+	switch {
+	case g.fast:
+		g.Printf("var (\n\t_ = fmt.Sprint\n\t_ = strconv.Itoa\n\t_ = strings.ToLower\n)\n\n")
+	case g.hasEmptyMsg():
+		g.Printf("var _ = fmt.Sprint\n\n")
+	}
+}
+
+// commonDecls generates the declarations shared by the errors of all types.
+func (g *Generator) commonDecls() {
+	// Generate the structure holding the cause.
+	g.Printf("type %s struct{ cause error }\n", g.wrapTypeName())
+	g.Printf("func (w *%s) Unwrap() error { return w.cause }\n\n", g.wrapTypeName())
+
+	if g.stack {
+		// Generate helpers to capture and resolve stack traces. Skip runtime.Callers,
+		// _errCallers and the error constructor.
+		g.Printf(`func _errCallers() []uintptr {
+	var pcs [32]uintptr
+	n := runtime.Callers(3, pcs[:])
+	return pcs[:n]
+}
+
+func _errFrames(pcs []uintptr) []runtime.Frame {
+	frames := runtime.CallersFrames(pcs)
+	st := make([]runtime.Frame, 0, len(pcs))
+	for {
+		f, more := frames.Next()
+		st = append(st, f)
+		if !more {
+			return st
+		}
+	}
+}
+
+`)
+	}
+
+	if g.short {
+		// Generate helper returning the own message of a cause, without the causes it wraps.
+		g.Printf(`func _errShort(err error) string {
+	if err == nil {
+		return "<nil>"
+	}
+	if e, ok := err.(interface{ errMsg() string }); ok {
+		return e.errMsg()
+	}
+	return err.Error()
+}
+
+`)
+	}
+
+	if g.context {
+		// Generate helper formatting the context of an error, sorted by key.
+		g.Printf(`func _errContext(ctx map[string]interface{}) string {
+	if len(ctx) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(ctx))
+	for k := range ctx {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	s := " ["
+	for i, k := range keys {
+		if i > 0 {
+			s += " "
+		}
+		s += fmt.Sprintf("%%s=%%v", k, ctx[k])
+	}
+	return s + "]"
+}
+
+`)
+	}
+
+	if g.hasWrapMode(MultiWrap) {
+		// Generate helper to join the messages of multiple causes.
+		g.Printf(`func _errJoin(errs []error) string {
+	s := ""
+	for i, err := range errs {
+		if i > 0 {
+			s += "; "
+		}
+		s += %s
+	}
+	return s
+}
+
+`, g.causeMsg("err"))
+	}
+}
+
+// typeDecls generates the declarations for the type of the error specifications.
+func (g *Generator) typeDecls() {
+	switch {
+	case g.noIs:
+		// Neither errors.Is nor IsIn can match the errors of the type.
+	case g.compatIs:
+		g.Printf("func (%s) Error() string { panic(\"Should not be called\") }\n\n", g.typeName)
+	default:
+		// Any error can be in the chain, not only the generated ones.
+		g.Printf(`func (e %[1]s) IsIn(err error) bool {
+	for err != nil {
+		if ei, ok := err.(interface{ Is(%[1]s) bool }); ok && ei.Is(e) {
+			return true
+		}
+		if multi, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, err := range multi.Unwrap() {
+				if e.IsIn(err) {
+					return true
+				}
+			}
+			return false
+		}
+		err = errors.Unwrap(err)
+	}
+	return false
+}`, g.typeName)
+		g.Printf("\n\n")
+	}
+	if g.retIface && (g.hasWrapMode(OptWrap) || g.hasWrapMode(MustWrap)) {
+		// Generate the interface returned by the constructors of errors that can wrap.
+		g.Printf("// %s is returned by the constructors of the %s errors that can wrap a cause.\n",
+			g.wrapperName(), g.typeName)
+		g.Printf("type %s interface {\n\terror\n\tWrap(error) error\n}\n\n", g.wrapperName())
+	}
+}
+
+// wrapperName returns the name of the interface returned by the constructors of errors that
+// can wrap, with -return-interface.
+func (g *Generator) wrapperName() string { return g.typeName + "Wrapper" }
+
+// ifaceName returns the name of the -interface interface as referenced by the generated code,
+// qualified by the name of its package if given.
+func (g *Generator) ifaceName() string {
+	i := strings.LastIndexByte(g.iface, '.')
+	if i < 0 {
+		return g.iface
+	}
+	name := g.ifacePkg
+	if name == "" {
+		name = assumedName(g.iface[:i])
+	}
+	return name + g.iface[i:]
+}
+
+// ifacePackage returns the name declared by the package of the -interface interface, loaded
+// from dir, or the empty string when the interface is not qualified by an import path or its
+// package cannot be loaded.
+func (g *Generator) ifacePackage(dir string) string {
+	i := strings.LastIndexByte(g.iface, '.')
+	if i < 0 {
+		return ""
+	}
+	imp := g.iface[:i]
+	for _, s := range g.imports {
+		if alias, _ := splitImport(s); alias == imp {
+			return ""
+		}
+	}
+	pkgs, err := packages.Load(&packages.Config{Mode: packages.NeedName, Dir: dir}, imp)
+	if err != nil || len(pkgs) != 1 || pkgs[0].Name == "" {
+		g.verbosef("cannot load package %s of -interface, assuming it is named %s", imp, assumedName(imp))
+		return ""
+	}
+	return pkgs[0].Name
+}
+
+// assumedName guesses the name of a package from its import path, as goimports does: the last
+// element, skipping a major version suffix, without a go- prefix and cut at a dot or hyphen.
+func assumedName(importPath string) string {
+	name := path.Base(importPath)
+	if majorRE.MatchString(name) && path.Dir(importPath) != "." {
+		name = path.Base(path.Dir(importPath))
+	}
+	name = strings.TrimPrefix(name, "go-")
+	if i := strings.IndexAny(name, ".-"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// majorRE matches the major version suffix of an import path, e.g. v2.
+var majorRE = regexp.MustCompile(`^v[0-9]+$`)
+
+// ctorResult returns the result type of the constructors of an error.
+func (g *Generator) ctorResult(structName string, t ParsedTemplate) string {
+	switch {
+	case !g.retIface:
+		return "*" + structName
+	case t.wrap == OptWrap || t.wrap == MustWrap:
+		return g.wrapperName()
+	}
+	return "error"
+}
+
+// body generates the declarations for the type and all its errors.
+func (g *Generator) body() {
+	g.typeDecls()
+	for _, spec := range g.specs {
+		g.generate(spec)
+	}
+	g.footer()
+}
+
+// outputName returns the name of the output file: the one given with -output, or the lowercase
+// name of the type followed by the suffix, in dir.
+func outputName(output, dir, typeName, suffix string) string {
+	if output != "" {
+		return output
+	}
+	return filepath.Join(dir, strings.ToLower(typeName)+suffix)
+}
+
+// generateFile generates a file holding the common declarations and the errors of all the
+// given generators, which have to share the same options as file.
+func generateFile(file *Generator, gens []*Generator) ([]byte, error) {
+	var imports []string
+	for _, g := range gens {
+		file.specs = append(file.specs, g.specs...)
+		imports = append(imports, g.importList()...)
+	}
+	file.pkgName = gens[0].pkgName
+	if err := file.fileHeader(append(imports, file.commonImports()...)); err != nil {
+		return nil, err
+	}
+	file.importRefs()
+	file.commonDecls()
+	for _, g := range gens {
+		g.body()
+		file.buf.Write(g.buf.Bytes())
+	}
+	file.testDecls(gens)
+	return file.format()
+}
+
+// testDecls generates the helpers for tests, covering the errors of all the given generators.
+func (g *Generator) testDecls(gens []*Generator) {
+	if !g.testHelpers {
+		return
+	}
+	name := "sameKind"
+	if g.makePub {
+		name = "SameKind"
+	}
+	// Generate SameKind, checking whether both errors match the same specification.
+	g.Printf("func %s(a, b error) bool {\n", name)
+	for _, gen := range gens {
+		g.Printf("\tfor _, k := range []%s{%s} {\n", gen.typeName, strings.Join(gen.generated, ", "))
+		if g.compatIs {
+			g.Printf("\t\tif errors.Is(a, k) && errors.Is(b, k) {\n")
+		} else {
+			g.Printf("\t\tif k.IsIn(a) && k.IsIn(b) {\n")
+		}
+		g.Printf("\t\t\treturn true\n\t\t}\n\t}\n")
+	}
+	g.Printf("\treturn false\n}\n\n")
+}
+
+// generateSplit generates a file for each of the given generators, without the common
+// declarations, and a file with the common declarations only.
+func generateSplit(common *Generator, gens []*Generator) ([][]byte, error) {
+	srcs := make([][]byte, 0, len(gens)+1)
+	for _, g := range gens {
+		if err := g.fileHeader(g.importList()); err != nil {
+			return nil, err
+		}
+		g.importRefs()
+		g.body()
+		src, err := g.format()
+		if err != nil {
+			return nil, err
+		}
+		srcs = append(srcs, src)
+		common.specs = append(common.specs, g.specs...)
+	}
+	common.pkgName = gens[0].pkgName
+	if err := common.fileHeader(common.commonImports()); err != nil {
+		return nil, err
+	}
+	common.commonDecls()
+	common.testDecls(gens)
+	src, err := common.format()
+	if err != nil {
+		return nil, err
+	}
+	return append(srcs, src), nil
+}
+
+// patternName returns the name of the variable holding the compiled pattern of a field.
+func patternName(structName string, f Field) string {
+	r, size := utf8.DecodeRuneInString(f.name)
+	return "_" + structName + string(unicode.ToUpper(r)) + f.name[size:] + "RE"
+}
+
+// generateChecks generates the validation of the fields having a pattern, panicking in the
+// constructor when a value does not match.
+func (g *Generator) generateChecks(ctorName, structName string, t ParsedTemplate) {
+	for _, f := range t.roots {
+		if f.re == "" {
+			continue
+		}
+		re, val := patternName(structName, f), f.name
+		if g.optionFields(t)[f.name] {
+			val = "o." + f.name
+		}
+		if isOptional(t, f.name) {
+			// An optional field left out is not checked.
+			g.Printf("\tif %s != \"\" && !%s.MatchString(%s) {\n", val, re, val)
+		} else {
+			g.Printf("\tif !%s.MatchString(%s) {\n", re, val)
+		}
+		g.Printf("\t\tpanic(fmt.Sprintf(\"%s: %s %%q does not match %%s\", %s, %s))\n\t}\n",
+			ctorName, f.name, val, re)
+	}
+}
+
+// isOptional reports whether a placeholder of the named field is optional, so that the field
+// can be left out.
+func isOptional(t ParsedTemplate, name string) bool {
+	for _, f := range t.fields {
+		if f.name == name && f.opt {
+			return true
+		}
+	}
+	return false
+}
+
+// hasWrapMode reports whether any of the specifications uses the given wrap mode.
+func (g *Generator) hasWrapMode(mode WrapMode) bool {
+	for _, spec := range g.specs {
+		if spec.parsed.wrap == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// hasEmptyMsg reports whether any of the specifications has an empty message.
+func (g *Generator) hasEmptyMsg() bool {
+	for _, spec := range g.specs {
+		if t := spec.parsed; t.fmt == "" && t.dynamic == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPattern reports whether any of the specifications has a field validated by a pattern.
+func (g *Generator) hasPattern() bool {
+	for _, spec := range g.specs {
+		for _, f := range spec.parsed.fields {
+			if f.re != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasDeepEqual reports whether any error has a field compared with reflect.DeepEqual.
+func (g *Generator) hasDeepEqual() bool {
+	for _, spec := range g.specs {
+		for _, f := range spec.parsed.roots {
+			if deepEqual(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// deepEqual reports whether a field is compared with reflect.DeepEqual by Equal methods, as
+// variadic fields, stored in slices, cannot be compared with ==.
+func deepEqual(f Field) bool {
+	return strings.HasPrefix(f.typ, "...")
+}
+
+// fieldImports returns the import paths needed by qualified field types (e.g. time.Duration)
+// that are not already in imports. Only standard library packages whose import path
+// matches the package name can be inferred, the others have to be given with -import.
+func (g *Generator) fieldImports(imports []string) []string {
+	known := make(map[string]bool, len(imports))
+	for _, imp := range imports {
+		alias, p := splitImport(imp)
+		if alias == "" {
+			alias = path.Base(p)
+		}
+		known[alias] = true
+	}
+	var extra []string
+	for _, spec := range g.specs {
+		for _, f := range spec.parsed.fields {
+			sel := typePackage(f.typ)
+			if sel == "" || known[sel] {
+				continue
+			}
+			known[sel] = true
+			pkg, err := build.Import(sel, "", build.FindOnly)
+			if err != nil || !pkg.Goroot {
+				g.logf("warning: cannot infer import path of package %q used by field %q "+
+					"of %s; add it with -import", sel, f.name, spec.name)
+				continue
+			}
+			extra = append(extra, sel)
+		}
+	}
+	return extra
+}
+
+// hasImport reports whether the imports include the package with the given path, or the
+// given name, as with pkg of a type pkg.T.
+func hasImport(imports []string, pkg string) bool {
+	for _, imp := range imports {
+		alias, p := splitImport(imp)
+		if p == pkg || alias == pkg || (alias == "" && path.Base(p) == pkg) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitImport splits an import given as alias=path, the alias is empty if not given.
+func splitImport(imp string) (alias, path string) {
+	if i := strings.IndexByte(imp, '='); i >= 0 {
+		return imp[:i], imp[i+1:]
+	}
+	return "", imp
+}
+
+// typePackage returns the package selector of a qualified type, or the empty string.
+func typePackage(typ string) string {
+	typ = strings.TrimLeft(typ, "*[].")
+	if i := strings.Index(typ, "."); i > 0 {
+		return typ[:i]
+	}
+	return ""
+}
+
+// fieldType returns the type of the struct field holding a template field, turning variadic
+// ...T types into slices.
+func fieldType(typ string) string {
+	if strings.HasPrefix(typ, "...") {
+		return "[]" + strings.TrimPrefix(typ, "...")
+	}
+	return typ
+}
+
+// generate generates the code for a single error implementations.
+func (g *Generator) generate(spec ErrorSpec) {
+	g.generated = append(g.generated, spec.name)
+	structName := g.structName(spec.name)
+	template := spec.parsed
+	doc := deprecatedDoc(spec.doc, template)
+
+	if g.sepComment {
+		// Generate divider, detached from the doc comment.
+		g.Printf("// ---- %s ----\n\n", spec.name)
+	}
+
+	// Generate structure for error.
+	g.printDoc(doc)
+	g.Printf("type %s struct {\n", structName)
+	switch {
+	case template.causeName != "":
+		// The cause is declared with a field of type error, which is unwrapped below.
+		g.Printf("\tcause error\n")
+	case template.wrap == OptWrap, template.wrap == MustWrap:
+		g.Printf("\t%s\n", g.wrapTypeName())
+	case template.wrap == MultiWrap:
+		g.Printf("\tcauses []error\n")
+	}
+	for _, f := range template.roots {
+		g.Printf("\t%s %s\n", f.name, fieldType(f.typ))
+	}
+	if g.stack {
+		g.Printf("\tstack []uintptr\n")
+	}
+	if g.suppressed {
+		g.Printf("\tsuppressed []error\n")
+	}
+	if g.reqIDKey != "" {
+		g.Printf("\trequestID string\n")
+	}
+	if g.logOnce {
+		// A pointer keeps errors copyable, copies share the flag.
+		g.Printf("\tlogged *atomic.Bool\n")
+	}
+	if g.metadata {
+		g.Printf("\tmeta map[string]string\n")
+	}
+	if g.context {
+		g.Printf("\tctx map[string]interface{}\n")
+	}
+	if g.cacheMsg {
+		g.Printf("\tmsg string\n")
+	}
+	g.Printf("}\n\n")
+
+	// Generate constructor with all arguments.
+	var params []string
+	for _, p := range g.ctorParams(template) {
+		params = append(params, p.name+" "+p.typ)
+	}
+	opts := g.optionFields(template)
+	if len(opts) > 0 {
+		params = append(params, "opts ..."+g.optionType(structName))
+	}
+	// applyOpts generates the code applying the options to a scratch error, which the optional
+	// fields are then taken from.
+	applyOpts := func() {
+		if len(opts) > 0 {
+			g.Printf("\tvar o %s\n\tfor _, opt := range opts {\n\t\topt(&o)\n\t}\n", structName)
+		}
+	}
+	values := make([]string, 0, len(template.roots)+3)
+	switch {
+	case template.causeName != "":
+		values = append(values, template.causeName)
+	case template.wrap == OptWrap:
+		values = append(values, g.wrapTypeName()+"{nil}")
+	case template.wrap == MustWrap:
+		values = append(values, g.wrapTypeName()+"{"+g.causeParam(template)+"}")
+	case template.wrap == MultiWrap:
+		values = append(values, "causes")
+	}
+	for _, f := range template.roots {
+		if opts[f.name] {
+			values = append(values, "o."+f.name)
+		} else {
+			values = append(values, f.name)
+		}
+	}
+	if g.stack {
+		values = append(values, "_errCallers()")
+	}
+	if g.suppressed {
+		values = append(values, "nil")
+	}
+	if g.reqIDKey != "" {
+		values = append(values, `""`)
+	}
+	if g.logOnce {
+		values = append(values, "new(atomic.Bool)")
+	}
+	if g.metadata {
+		values = append(values, "nil")
+	}
+	if g.context {
+		values = append(values, "nil")
+	}
+	if g.cacheMsg {
+		values = append(values, `""`)
+	}
+	ctorName := g.ctorName(structName)
+	for _, f := range template.roots {
+		if f.re != "" {
+			// Generate the compiled pattern validating the field.
+			g.Printf("var %s = regexp.MustCompile(%q)\n\n", patternName(structName, f), f.re)
+		}
+	}
+	printCtor := func(name string, params, values []string) {
+		g.Printf("func %s(%s) %s {\n", name, strings.Join(params, ", "), g.ctorResult(structName, template))
+		applyOpts()
+		g.generateChecks(name, structName, template)
+		if g.cacheMsg {
+			g.Printf("\t_e := &%s{%s}\n\t_e.msg = _e.formatMsg()\n\treturn _e\n}\n\n",
+				structName, strings.Join(values, ", "))
+		} else {
+			g.Printf("\treturn &%s{%s}\n}\n\n", structName, strings.Join(values, ", "))
+		}
+	}
+	g.printDoc(doc)
+	printCtor(ctorName, params, values)
+
+	if g.wrapCtor && template.wrap == OptWrap && template.causeName == "" {
+		// Generate constructor also taking the cause, placed like for wrap: errors.
+		mustWrap := template
+		mustWrap.wrap = MustWrap
+		var wrapParams []string
+		for _, p := range g.ctorParams(mustWrap) {
+			wrapParams = append(wrapParams, p.name+" "+p.typ)
+		}
+		if len(opts) > 0 {
+			wrapParams = append(wrapParams, "opts ..."+g.optionType(structName))
+		}
+		wrapValues := append([]string{g.wrapTypeName() + "{" + g.causeParam(template) + "}"}, values[1:]...)
+		g.Printf("// %s%s is like %s, also wrapping a cause.\n", ctorName, g.wrapCtorSfx, ctorName)
+		printCtor(ctorName+g.wrapCtorSfx, wrapParams, wrapValues)
+	}
+
+	if g.reqIDKey != "" {
+		// Generate constructor taking the request ID from a context. Its identifiers start with an
+		// underscore, not to collide with the parameters of the fields.
+		params = append([]string{"_ctx context.Context"}, params...)
+		g.Printf("func %sCtx(%s) %s {\n", ctorName, strings.Join(params, ", "), g.ctorResult(structName, template))
+		applyOpts()
+		g.generateChecks(ctorName+"Ctx", structName, template)
+		g.Printf("\t_e := &%s{%s}\n", structName, strings.Join(values, ", "))
+		if g.cacheMsg {
+			g.Printf("\t_e.msg = _e.formatMsg()\n")
+		}
+		g.Printf("\t_e.requestID, _ = _ctx.Value(%s).(string)\n\treturn _e\n}\n\n", g.reqIDKey)
+	}
+
+	if len(opts) > 0 {
+		// Generate the option type and an option per optional field.
+		optType := g.optionType(structName)
+		g.Printf("// %s sets an optional field of %s.\n", optType, structName)
+		g.Printf("type %s func(*%s)\n\n", optType, structName)
+		for _, f := range template.roots {
+			if !opts[f.name] {
+				continue
+			}
+			g.Printf("// %s sets the optional field %s of %s.\n", g.optionFunc(structName, f), f.name, structName)
+			g.Printf("func %s(%s %s) %s {\n", g.optionFunc(structName, f), f.name, fieldType(f.typ), optType)
+			g.Printf("\treturn func(e *%[1]s) { e.%[2]s = %[2]s }\n}\n\n", structName, f.name)
+		}
+	}
+
+	if g.sentinels && template.wrap == NoWrap && len(template.roots) == 0 {
+		// Generate shared instance, for errors whose constructor takes no arguments.
+		g.Printf("// %sInstance is a shared instance of %s, matched by errors.Is.\n", structName, spec.name)
+		g.Printf("var %sInstance = %s()\n\n", structName, ctorName)
+	}
+
+	// Generate Error method, or the method computing the message to cache.
+	switch {
+	case g.cacheMsg && g.context:
+		g.Printf("func (e *%s) Error() string { return e.msg + _errContext(e.ctx) }\n\n", structName)
+		g.Printf("func (e *%s) formatMsg() string {\n", structName)
+	case g.cacheMsg:
+		g.Printf("func (e *%s) Error() string { return e.msg }\n\n", structName)
+		g.Printf("func (e *%s) formatMsg() string {\n", structName)
+	case g.context:
+		// The context follows the whole message, computed by formatMsg.
+		g.Printf("func (e *%s) Error() string { return e.formatMsg() + _errContext(e.ctx) }\n\n", structName)
+		g.Printf("func (e *%s) formatMsg() string {\n", structName)
+	default:
+		g.Printf("func (e *%s) Error() string {\n", structName)
+	}
+	switch {
+	case template.dynamic != "":
+		g.Printf("\treturn %s(e)\n", template.dynamic)
+	case template.causeIdx >= 0:
+		// The cause is placed inline, weave it into the Sprintf arguments.
+		cause := g.causeArg()
+		if template.wrap == MultiWrap {
+			cause = "_errJoin(e.causes)"
+		}
+		g.Printf("\treturn fmt.Sprintf(%q", template.fmt)
+		for i, f := range template.fields {
+			if i == template.causeIdx {
+				g.Printf(", %s", cause)
+			}
+			g.Printf(", e.%s", f.val)
+		}
+		if template.causeIdx == len(template.fields) {
+			g.Printf(", %s", cause)
+		}
+		g.Printf(")\n")
+	case hasOpt(template):
+		// The own message is built by errMsg, leaving out the zero optional fields.
+		switch template.wrap {
+		case OptWrap:
+			g.Printf("\tif e.cause == nil {\n\t\treturn e.errMsg()\n\t}\n")
+			g.Printf("\treturn fmt.Sprintf(%q, e.errMsg(), %s)\n", "%s: "+g.causeVerb(), g.causeArg())
+		case NoWrap:
+			g.Printf("\treturn e.errMsg()\n")
+		case MustWrap:
+			g.Printf("\treturn fmt.Sprintf(%q, e.errMsg(), %s)\n", "%s: "+g.causeVerb(), g.causeArg())
+		case MultiWrap:
+			g.Printf("\tif len(e.causes) == 0 {\n\t\treturn e.errMsg()\n\t}\n")
+			g.Printf("\treturn e.errMsg() + \": \" + _errJoin(e.causes)\n")
+		}
+	case template.fmt == "":
+		// Without an own message, the error reads as its cause.
+		switch template.wrap {
+		case OptWrap:
+			g.Printf("\tif e.cause == nil {\n\t\treturn \"\"\n\t}\n")
+			g.Printf("\treturn fmt.Sprintf(%q, %s)\n", g.causeVerb(), g.causeArg())
+		case NoWrap:
+			g.Printf("\treturn \"\"\n")
+		case MustWrap:
+			g.Printf("\treturn fmt.Sprintf(%q, %s)\n", g.causeVerb(), g.causeArg())
+		case MultiWrap:
+			g.Printf("\treturn _errJoin(e.causes)\n")
+		}
+	case g.fast:
+		g.generateFastError(template)
+	case template.wrap == OptWrap:
+		g.Printf("\tif e.cause == nil {\n\t\treturn fmt.Sprintf(%q", template.fmt)
+		// Add call to Sprintf w/o cause.
+		for _, f := range template.fields {
+			g.Printf(", e.%s", f.val)
+		}
+		g.Printf(")\n\t}\n\treturn fmt.Sprintf(%q, ", template.fmt+": "+g.causeVerb())
+		// Add params to Sprintf w/ cause.
+		for _, f := range template.fields {
+			g.Printf("e.%s, ", f.val)
+		}
+		g.Printf("%s)\n", g.causeArg())
+	case template.wrap == NoWrap:
+		g.Printf("\treturn fmt.Sprintf(%q", template.fmt)
+		for _, f := range template.fields {
+			g.Printf(", e.%s", f.val)
+		}
+		g.Printf(")\n")
+	case template.wrap == MustWrap:
+		g.Printf("\treturn fmt.Sprintf(%q, ", template.fmt+": "+g.causeVerb())
+		// Add params to Sprintf w/ cause.
+		for _, f := range template.fields {
+			g.Printf("e.%s, ", f.val)
+		}
+		g.Printf("%s)\n", g.causeArg())
+	case template.wrap == MultiWrap:
+		g.Printf("\tif len(e.causes) == 0 {\n\t\treturn fmt.Sprintf(%q", template.fmt)
+		// Add call to Sprintf w/o causes.
+		for _, f := range template.fields {
+			g.Printf(", e.%s", f.val)
+		}
+		g.Printf(")\n\t}\n\treturn fmt.Sprintf(%q, ", template.fmt+": %s")
+		// Add params to Sprintf w/ joined causes.
+		for _, f := range template.fields {
+			g.Printf("e.%s, ", f.val)
+		}
+		g.Printf("_errJoin(e.causes))\n")
+	}
+	g.Printf("}\n")
+
+	if g.short || (hasOpt(template) && template.dynamic == "") {
+		// Generate the method returning the own message, used when this error is a cause.
+		g.Printf("\nfunc (e *%s) errMsg() string {\n", structName)
+		if template.dynamic == "" && hasOpt(template) {
+			g.generateOptMsg(template)
+		} else if template.dynamic == "" && template.causeIdx < 0 && template.wrap != NoWrap {
+			g.Printf("\treturn fmt.Sprintf(%q%s)\n}\n", template.fmt, fieldArgs(template))
+		} else {
+			g.Printf("\treturn e.Error()\n}\n")
+		}
+	}
+
+	hasCause := template.wrap == OptWrap || template.wrap == MustWrap
+	if hasCause {
+		// Generate Wrap method, setting the cause on a copy if immutable.
+		g.Printf("\nfunc (e *%s) Wrap(cause error) error {\n", structName)
+		recv := "e"
+		if g.immutable {
+			g.Printf("\tc := *e\n")
+			recv = "c"
+		}
+		g.Printf("\t%s.cause = cause\n", recv)
+		if g.cacheMsg {
+			g.Printf("\t%[1]s.msg = %[1]s.formatMsg()\n", recv)
+		}
+		if g.immutable {
+			g.Printf("\treturn &c\n}\n")
+		} else {
+			g.Printf("\treturn e\n}\n")
+		}
+	}
+
+	if g.equal {
+		g.generateEqual(structName, template)
+	}
+
+	if hasCause && g.causer {
+		// Generate Cause method, followed by errors.Cause of github.com/pkg/errors.
+		g.Printf("\nfunc (e *%s) Cause() error { return e.cause }\n", structName)
+	}
+
+	if g.fmtModes {
+		g.generateFormat(structName, template)
+	}
+
+	if g.cliMethod {
+		g.generateCLIString(structName, template)
+	}
+
+	if g.goString {
+		g.generateGoString(structName, template)
+	}
+
+	if g.stringer {
+		// Generate String method.
+		g.Printf("\nfunc (e *%s) String() string { return e.Error() }\n", structName)
+	}
+
+	if g.text {
+		// Generate MarshalText method.
+		g.Printf("\nfunc (e *%s) MarshalText() ([]byte, error) { return []byte(e.Error()), nil }\n", structName)
+	}
+
+	if g.reqIDKey != "" {
+		// Generate RequestID accessor.
+		g.Printf("\nfunc (e *%s) RequestID() string { return e.requestID }\n", structName)
+	}
+
+	if g.logOnce {
+		// Generate MarkLogged method.
+		g.Printf("\nfunc (e *%s) MarkLogged() bool { return !e.logged.Swap(true) }\n", structName)
+	}
+
+	if g.metadata {
+		// Generate metadata setter, allocating the map on first use, and accessor.
+		g.Printf(`
+func (e *%[1]s) WithMeta(k, v string) *%[1]s {
+	if e.meta == nil {
+		e.meta = make(map[string]string)
+	}
+	e.meta[k] = v
+	return e
+}
+
+func (e *%[1]s) Meta() map[string]string { return e.meta }
+`, structName)
+	}
+
+	if g.context {
+		// Generate context setter, allocating the map on first use.
+		g.Printf(`
+func (e *%[1]s) With(key string, val interface{}) *%[1]s {
+	if e.ctx == nil {
+		e.ctx = make(map[string]interface{})
+	}
+	e.ctx[key] = val
+	return e
+}
+`, structName)
+	}
+
+	if hasCause && g.wrapped {
+		// Generate Wrapped accessor.
+		g.Printf("\nfunc (e *%s) Wrapped() error { return e.cause }\n", structName)
+	}
+
+	if g.suppressed {
+		// Generate methods to add suppressed errors and unwrap them with the cause.
+		g.Printf(`
+func (e *%[1]s) AddSuppressed(err error) {
+	e.suppressed = append(e.suppressed, err)
+}
+`, structName)
+		switch template.wrap {
+		case NoWrap:
+			g.Printf("\nfunc (e *%s) Unwrap() []error { return e.suppressed }\n", structName)
+		case MultiWrap:
+			g.Printf(`
+func (e *%s) Unwrap() []error {
+	return append(append([]error{}, e.causes...), e.suppressed...)
+}
+`, structName)
+		default:
+			g.Printf(`
+func (e *%s) Unwrap() []error {
+	if e.cause == nil {
+		return e.suppressed
+	}
+	return append([]error{e.cause}, e.suppressed...)
+}
+`, structName)
+		}
+	}
+
+	if template.causeName != "" && !g.suppressed {
+		// Generate Unwrap method for the cause declared as a field.
+		g.Printf("\nfunc (e *%s) Unwrap() error { return e.cause }\n", structName)
+	}
+
+	if template.wrap == MultiWrap && !g.suppressed {
+		// Generate Unwrap method for multiple causes.
+		g.Printf("\nfunc (e *%s) Unwrap() []error { return e.causes }\n", structName)
+	}
+
+	if g.stack {
+		// Generate StackTrace method.
+		g.Printf(`
+func (e *%s) StackTrace() []runtime.Frame {
+	return _errFrames(e.stack)
+}
+`, structName)
+	}
+
+	// Generate Is method.
+	if g.noIs {
+		g.Printf("\n")
+	} else if g.matchFields {
+		g.generateMatchFieldsIs(spec.name, structName, template)
+	} else if g.compatIs {
+		g.Printf("\nfunc (*%s) Is(e error) bool { return e == %s }\n\n", structName, spec.name)
+	} else {
+		g.Printf("\nfunc (*%s) Is(e %s) bool { return e == %s }\n\n", structName, g.typeName, spec.name)
+	}
+
+	if template.exitCode >= 0 {
+		// Generate ExitCode method.
+		g.Printf("func (*%s) ExitCode() int { return %d }\n\n", structName, template.exitCode)
+	}
+
+	if template.net {
+		// Generate the methods of net.Error.
+		g.Printf("func (*%s) Temporary() bool { return %t }\n\n", structName, template.temporary)
+		g.Printf("func (*%s) Timeout() bool { return %t }\n\n", structName, template.timeout)
+	}
+
+	if template.severity != "" {
+		// Generate Severity method.
+		g.Printf("func (*%s) Severity() string { return %q }\n\n", structName, template.severity)
+	}
+
+	if g.codeEnum {
+		// Generate Code method.
+		g.Printf("func (*%s) Code() %s { return %s }\n\n", structName, g.codeTypeName(), spec.name+"Code")
+	}
+
+	if template.replacement != "" {
+		// Generate ReplacedBy method, pointing at the error to use instead.
+		g.Printf("func (*%s) ReplacedBy() %s { return %s }\n\n", structName, g.typeName, template.replacement)
+	}
+
+	if template.class != "" {
+		// Generate client/server error class methods.
+		g.Printf("func (*%s) IsClientError() bool { return %t }\n\n", structName, template.class == "client")
+		g.Printf("func (*%s) IsServerError() bool { return %t }\n\n", structName, template.class == "server")
+	}
+
+	if g.codeMatches {
+		// Generate CodeMatches method, to compare against legacy string codes.
+		g.Printf("func (*%s) CodeMatches(code string) bool { return code == %q }\n\n",
+			structName, spec.name)
+	}
+
+	if g.domain != "" {
+		// Generate Domain method.
+		g.Printf("func (*%s) Domain() string { return %q }\n\n", structName, g.domain)
+	}
+
+	if g.iface != "" {
+		// Generate assertion that the error implements the interface.
+		g.Printf("var _ %s = (*%s)(nil)\n\n", g.ifaceName(), structName)
+	}
+
+	if g.isFunc {
+		// Generate package-level predicate, looking for the error in the chain.
+		funcName := "is" + strings.Title(structName)
+		if g.makePub {
+			funcName = "Is" + strings.Title(structName)
+		}
+		g.Printf("func %s(err error) bool {\n\tvar e *%s\n\treturn errors.As(err, &e)\n}\n\n",
+			funcName, structName)
+	}
+
+	if g.asHelpers {
+		// Generate package-level helper, extracting the error from the chain. The order of
+		// evaluation of e and errors.As in a single return statement is unspecified.
+		funcName := "as" + strings.Title(structName)
+		if g.makePub {
+			funcName = "As" + strings.Title(structName)
+		}
+		g.Printf("func %[1]s(err error) (*%[2]s, bool) {\n\tvar e *%[2]s\n\tok := errors.As(err, &e)\n\treturn e, ok\n}\n\n",
+			funcName, structName)
+	}
+}
+
+// generateEqual generates an Equal method, reporting whether two errors have equal fields and
+// causes matching with errors.Is.
+func (g *Generator) generateEqual(structName string, t ParsedTemplate) {
+	g.Printf("\nfunc (e *%[1]s) Equal(other *%[1]s) bool {\n", structName)
+	g.Printf("\tif e == nil || other == nil {\n\t\treturn e == other\n\t}\n")
+	var conds []string
+	for _, f := range t.roots {
+		if deepEqual(f) {
+			conds = append(conds, fmt.Sprintf("reflect.DeepEqual(e.%[1]s, other.%[1]s)", f.name))
+		} else {
+			conds = append(conds, fmt.Sprintf("e.%[1]s == other.%[1]s", f.name))
+		}
+	}
+	switch t.wrap {
+	case OptWrap, MustWrap:
+		conds = append(conds, "errors.Is(e.cause, other.cause)")
+	case MultiWrap:
+		g.Printf("\tif len(e.causes) != len(other.causes) {\n\t\treturn false\n\t}\n")
+		g.Printf("\tfor i, cause := range e.causes {\n")
+		g.Printf("\t\tif !errors.Is(cause, other.causes[i]) {\n\t\t\treturn false\n\t\t}\n\t}\n")
+	}
+	if len(conds) == 0 {
+		conds = append(conds, "true")
+	}
+	g.Printf("\treturn %s\n}\n", strings.Join(conds, " &&\n\t\t"))
+}
+
+// generateMatchFieldsIs generates an Is method matching the sentinel of the error, as well as
+// errors of the same kind whose fields are equal.
+func (g *Generator) generateMatchFieldsIs(specName, structName string, t ParsedTemplate) {
+	var conds []string
+	for _, f := range t.roots {
+		conds = append(conds, fmt.Sprintf("e.%[1]s == t.%[1]s", f.name))
+	}
+	g.Printf("\nfunc (e *%s) Is(target error) bool {\n", structName)
+	if len(conds) == 0 {
+		g.Printf("\tif _, ok := target.(*%s); ok {\n\t\treturn true\n\t}\n", structName)
+	} else {
+		g.Printf("\tif t, ok := target.(*%s); ok {\n\t\treturn %s\n\t}\n", structName, strings.Join(conds, " && "))
+	}
+	g.Printf("\treturn target == %s\n}\n\n", specName)
+}
+
+// deprecatedDoc returns the doc comment of an error, with a deprecation notice appended
+// when the template is deprecated.
+func deprecatedDoc(doc string, t ParsedTemplate) string {
+	if !t.deprecated {
+		return doc
+	}
+	note := "Deprecated: do not use."
+	if t.replacement != "" {
+		note = "Deprecated: use " + t.replacement + " instead."
+	}
+	if doc == "" {
+		return note
+	}
+	return doc + "\n\n" + note
+}
+
+// causeArg returns the argument formatting the cause appended to messages: the cause itself,
+// or its own message with -short.
+func (g *Generator) causeArg() string {
+	if g.short {
+		return "_errShort(e.cause)"
+	}
+	return "e.cause"
+}
+
+// causeMsg returns the expression of the message of a cause, which is its own message only
+// with -short.
+func (g *Generator) causeMsg(cause string) string {
+	if g.short {
+		return "_errShort(" + cause + ")"
+	}
+	return cause + ".Error()"
+}
+
+// wrapTypeName returns the name of the type embedded by errors to hold the cause.
+func (g *Generator) wrapTypeName() string {
+	if g.wrapType == "" {
+		return "_errWrap"
+	}
+	return g.wrapType
+}
+
+// causeVerb returns the formatting verb of the cause appended to messages.
+func (g *Generator) causeVerb() string {
+	if g.wrapVerb == "" {
+		return "%v"
+	}
+	return g.wrapVerb
+}
+
+// ctorName returns the name of the constructor of an error.
+func (g *Generator) ctorName(structName string) string {
+	if g.makePub {
+		return "New" + strings.Title(structName)
+	}
+	return "new" + strings.Title(structName)
+}
+
+// ctorParams returns the parameters of the constructor of an error, with their name and type.
+func (g *Generator) ctorParams(template ParsedTemplate) []Field {
+	params := make([]Field, 0, len(template.roots)+1)
+	opts := g.optionFields(template)
+	for _, f := range template.roots {
+		if !opts[f.name] {
+			params = append(params, Field{name: f.name, typ: f.typ})
+		}
+	}
+	cause := Field{name: g.causeParam(template), typ: "error"}
+	switch n := len(params); {
+	case template.causeName != "":
+		// The cause takes the place of its field.
+		params = append(params[:template.causePos:template.causePos], append([]Field{cause}, params[template.causePos:]...)...)
+	case template.wrap == MustWrap && g.causeFirst:
+		params = append([]Field{cause}, params...)
+	case template.wrap == MustWrap && n > 0 && strings.HasPrefix(params[n-1].typ, "..."):
+		// The variadic field has to be the last parameter.
+		params = append(params[:n-1], cause, params[n-1])
+	case template.wrap == MustWrap:
+		params = append(params, cause)
+	case template.wrap == MultiWrap:
+		// Variadic, always the last parameter.
+		params = append(params, Field{name: "causes", typ: "...error"})
+	}
+	return params
+}
+
+// optionFields returns the names of the root fields set with options, with -options: the ones
+// whose placeholders are all optional.
+func (g *Generator) optionFields(t ParsedTemplate) map[string]bool {
+	if !g.options {
+		return nil
+	}
+	opts := make(map[string]bool)
+	for _, f := range t.fields {
+		if _, ok := opts[f.name]; !ok || !f.opt {
+			opts[f.name] = f.opt
+		}
+	}
+	for name, opt := range opts {
+		if !opt {
+			delete(opts, name)
+		}
+	}
+	return opts
+}
+
+// optionType returns the name of the type of the options of an error.
+func (g *Generator) optionType(structName string) string { return structName + "Option" }
+
+// optionFunc returns the name of the function returning the option setting a field.
+func (g *Generator) optionFunc(structName string, f Field) string {
+	return structName + "With" + strings.Title(f.name)
+}
+
+// causeParam returns the name of the cause parameter of a constructor: the name of the field of
+// type error declaring it, the one given with -wrap-param, or cause when a field has the same
+// name, followed by underscores as needed.
+func (g *Generator) causeParam(template ParsedTemplate) string {
+	if template.causeName != "" {
+		return template.causeName
+	}
+	taken := make(map[string]bool, len(template.roots))
+	for _, f := range template.roots {
+		taken[f.name] = true
+	}
+	name := g.wrapParam
+	if name == "" {
+		name = "err"
+	}
+	if taken[name] {
+		name = "cause"
+	}
+	for taken[name] {
+		name += "_"
+	}
+	return name
+}
+
+// checkDuplicates verifies that the specifications of all the generators have distinct names,
+// and that their struct names are distinct after transformations such as -suffix.
+func checkDuplicates(gens []*Generator) error {
+	specs := make(map[string]bool)
+	structs := make(map[string]string)
+	var collisions []string
+	for _, g := range gens {
+		for _, spec := range g.specs {
+			if specs[spec.name] {
+				collisions = append(collisions, fmt.Sprintf("%s declared more than once", spec.name))
+				continue
+			}
+			specs[spec.name] = true
+			structName := g.structName(spec.name)
+			if other, ok := structs[structName]; ok {
+				collisions = append(collisions, fmt.Sprintf("%s and %s both generate %s", other, spec.name, structName))
+				continue
+			}
+			structs[structName] = spec.name
+		}
+	}
+	if len(collisions) > 0 {
+		return fmt.Errorf("duplicate errors: %s", strings.Join(collisions, ", "))
+	}
+	return nil
+}
+
+// checkReplacements verifies that the replacements of deprecated errors are errors of the
+// same type.
+func checkReplacements(gens []*Generator) error {
+	for _, g := range gens {
+		names := make(map[string]bool, len(g.specs))
+		for _, spec := range g.specs {
+			names[spec.name] = true
+		}
+		for _, spec := range g.specs {
+			r := spec.parsed.replacement
+			if r == spec.name {
+				return fmt.Errorf("%s: deprecated in favor of itself", spec.name)
+			}
+			if r != "" && !names[r] {
+				return fmt.Errorf("%s: replacement %s is not an error of type %s", spec.name, r, g.typeName)
+			}
+		}
+	}
+	return nil
+}
+
+// checkCoverage verifies that an error was generated for each constant of the error type,
+// reporting the ones that were skipped.
+func (g *Generator) checkCoverage() error {
+	generated := make(map[string]bool, len(g.generated))
+	for _, name := range g.generated {
+		generated[name] = true
+	}
+	var missing []string
+	for _, spec := range g.skipped {
+		missing = append(missing, fmt.Sprintf("%s (%s)", spec.name, spec.reason))
+	}
+	for _, spec := range g.specs {
+		if !generated[spec.name] {
+			missing = append(missing, fmt.Sprintf("%s (not generated)", spec.name))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("generated %d of %d errors of type %s, missing: %s",
+			len(g.generated), len(g.generated)+len(missing), g.typeName, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// generateFormat generates a Format method, printing the message without the cause with %s,
+// and with the cause with %v (and %+v, which is propagated to the cause). With -context, the
+// context follows the message in every case.
+func (g *Generator) generateFormat(structName string, template ParsedTemplate) {
+	g.Printf("\nfunc (e *%s) Format(f fmt.State, verb rune) {\n\tswitch verb {\n", structName)
+	ownMsg := template.dynamic == "" && template.causeIdx < 0 &&
+		(template.wrap == OptWrap || template.wrap == MustWrap)
+	ctxFmt, ctxArg := "", ""
+	if g.context {
+		ctxFmt, ctxArg = "%s", ", _errContext(e.ctx)"
+	}
+	if ownMsg && template.fmt == "" {
+		// Without an own message, only the cause is printed.
+		g.Printf("\tcase 's':\n")
+		if g.context {
+			g.Printf("\t\tfmt.Fprint(f, _errContext(e.ctx))\n")
+		}
+		g.Printf("\tcase 'v':\n\t\tif f.Flag('+') && e.cause != nil {\n")
+		g.Printf("\t\t\tfmt.Fprintf(f, %q, e.cause%s)\n\t\t\treturn\n\t\t}\n", "%+v"+ctxFmt, ctxArg)
+		g.Printf("\t\tfmt.Fprint(f, e.Error())\n")
+	} else if ownMsg {
+		msgFmt, msgArgs := ownFormat(template)
+		g.Printf("\tcase 's':\n\t\tfmt.Fprintf(f, %q%s%s)\n", msgFmt+ctxFmt, msgArgs, ctxArg)
+		g.Printf("\tcase 'v':\n\t\tif f.Flag('+') && e.cause != nil {\n")
+		g.Printf("\t\t\tfmt.Fprintf(f, %q%s, e.cause%s)\n\t\t\treturn\n\t\t}\n", msgFmt+": %+v"+ctxFmt, msgArgs, ctxArg)
+		g.Printf("\t\tfmt.Fprint(f, e.Error())\n")
+	} else {
+		g.Printf("\tcase 's', 'v':\n\t\tfmt.Fprint(f, e.Error())\n")
+	}
+	g.Printf("\tdefault:\n\t\tfmt.Fprintf(f, \"%%\"+string(verb), e.Error())\n\t}\n}\n")
+}
+
+// generateFastError generates the body of an Error method writing the message to a
+// strings.Builder. Literal segments are written as they are and fields are converted with
+// strconv when possible, falling back to fmt.Fprintf otherwise.
+func (g *Generator) generateFastError(template ParsedTemplate) {
+	g.Printf("\tvar b strings.Builder\n")
+	for i, f := range template.fields {
+		if seg := template.segments[i]; seg != "" {
+			g.Printf("\tb.WriteString(%q)\n", strings.ReplaceAll(seg, "%%", "%"))
+		}
+		if conv := fastConv(f); conv != "" {
+			g.Printf("\tb.WriteString(%s)\n", conv)
+		} else {
+			g.Printf("\tfmt.Fprintf(&b, \"%s\", e.%s)\n", f.fmt, f.val)
+		}
+	}
+	if seg := template.segments[len(template.fields)]; seg != "" {
+		g.Printf("\tb.WriteString(%q)\n", strings.ReplaceAll(seg, "%%", "%"))
+	}
+	switch {
+	case g.causeVerb() != "%v" && (template.wrap == OptWrap || template.wrap == MustWrap):
+		if template.wrap == OptWrap {
+			g.Printf("\tif e.cause != nil {\n\t\tfmt.Fprintf(&b, \": %s\", %s)\n\t}\n", g.causeVerb(), g.causeArg())
+		} else {
+			g.Printf("\tfmt.Fprintf(&b, \": %s\", %s)\n", g.causeVerb(), g.causeArg())
+		}
+	case template.wrap == OptWrap:
+		g.Printf("\tif e.cause != nil {\n\t\tb.WriteString(\": \")\n")
+		g.Printf("\t\tb.WriteString(%s)\n\t}\n", g.causeMsg("e.cause"))
+	case template.wrap == MustWrap:
+		// Match %v, which prints <nil> for a missing cause.
+		g.Printf("\tb.WriteString(\": \")\n\tif e.cause == nil {\n\t\tb.WriteString(\"<nil>\")\n")
+		g.Printf("\t} else {\n\t\tb.WriteString(%s)\n\t}\n", g.causeMsg("e.cause"))
+	case template.wrap == MultiWrap:
+		g.Printf("\tif len(e.causes) > 0 {\n\t\tb.WriteString(\": \")\n")
+		g.Printf("\t\tb.WriteString(_errJoin(e.causes))\n\t}\n")
+	}
+	g.Printf("\treturn b.String()\n")
+}
+
+// fastConv returns the expression converting a field to its formatted string with strconv, or
+// an empty string when the field has to be formatted with fmt. Only fields of basic types
+// accessed directly qualify, so that the result is the same as with fmt.
+func fastConv(f Field) string {
+	if f.val != f.name {
+		return ""
+	}
+	v := "e." + f.val
+	switch {
+	case f.typ == "string" && (f.fmt == "%s" || f.fmt == "%v"):
+		return v
+	case f.typ == "string" && f.fmt == "%q":
+		return "strconv.Quote(" + v + ")"
+	case f.typ == "bool" && (f.fmt == "%t" || f.fmt == "%v"):
+		return "strconv.FormatBool(" + v + ")"
+	case f.fmt != "%d" && f.fmt != "%v":
+		return ""
+	case f.typ == "int":
+		return "strconv.Itoa(" + v + ")"
+	case f.typ == "int8", f.typ == "int16", f.typ == "int32", f.typ == "int64":
+		return "strconv.FormatInt(int64(" + v + "), 10)"
+	case f.typ == "uint", f.typ == "uint8", f.typ == "uint16", f.typ == "uint32", f.typ == "uint64":
+		return "strconv.FormatUint(uint64(" + v + "), 10)"
+	}
+	return ""
+}
+
+// generateCLIString generates a CLIString method, returning the message colored with ANSI codes
+// and the cause, if any, dimmed. With -context, the context follows, not colored.
+func (g *Generator) generateCLIString(structName string, template ParsedTemplate) {
+	paint := func(expr, sgr string) string {
+		if g.cliColor == "" {
+			return expr
+		}
+		return fmt.Sprintf("\"\\x1b[%sm\" + %s + \"\\x1b[0m\"", sgr, expr)
+	}
+	// withCtx appends the context to the expression of the returned string.
+	withCtx := func(expr string) string {
+		switch {
+		case !g.context:
+			return expr
+		case expr == `""`:
+			return "_errContext(e.ctx)"
+		}
+		return expr + " + _errContext(e.ctx)"
+	}
+	g.Printf("\nfunc (e *%s) CLIString() string {\n", structName)
+	if template.dynamic != "" || template.causeIdx >= 0 || template.wrap == NoWrap {
+		// The cause cannot be told apart from the message.
+		g.Printf("\treturn %s\n}\n", paint("e.Error()", g.cliColor))
+		return
+	}
+	if template.fmt == "" {
+		// Without an own message, the error reads as its dimmed cause.
+		if template.wrap == MultiWrap {
+			g.Printf("\tif len(e.causes) == 0 {\n\t\treturn %s\n\t}\n\treturn %s\n}\n",
+				withCtx(`""`), withCtx(paint("_errJoin(e.causes)", "2")))
+		} else {
+			g.Printf("\tif e.cause == nil {\n\t\treturn %s\n\t}\n\treturn %s\n}\n",
+				withCtx(`""`), withCtx(paint("e.cause.Error()", "2")))
+		}
+		return
+	}
+	msgFmt, msgArgs := ownFormat(template)
+	g.Printf("\ts := %s\n", paint(fmt.Sprintf("fmt.Sprintf(%q%s)", msgFmt, msgArgs), g.cliColor))
+	if template.wrap == MultiWrap {
+		g.Printf("\tif len(e.causes) > 0 {\n\t\ts += \": \" + %s\n\t}\n", paint("_errJoin(e.causes)", "2"))
+	} else {
+		g.Printf("\tif e.cause != nil {\n\t\ts += \": \" + %s\n\t}\n", paint("e.cause.Error()", "2"))
+	}
+	g.Printf("\treturn %s\n}\n", withCtx("s"))
+}
+
+// generateGoString generates a GoString method, representing the error like a struct literal
+// with its fields, cause and context.
+func (g *Generator) generateGoString(structName string, template ParsedTemplate) {
+	keys := make([]string, 0, len(template.roots)+1)
+	args := make([]string, 0, len(template.roots)+1)
+	for _, f := range template.roots {
+		keys = append(keys, f.name+": %#v")
+		args = append(args, "e."+f.name)
+	}
+	switch template.wrap {
+	case OptWrap, MustWrap:
+		keys = append(keys, "cause: %#v")
+		args = append(args, "e.cause")
+	case MultiWrap:
+		keys = append(keys, "causes: %#v")
+		args = append(args, "e.causes")
+	}
+	if g.context {
+		keys = append(keys, "ctx: %#v")
+		args = append(args, "e.ctx")
+	}
+	g.Printf("\nfunc (e *%s) GoString() string {\n", structName)
+	g.Printf("\treturn fmt.Sprintf(\"%s{%s}\"", structName, strings.Join(keys, ", "))
+	for _, arg := range args {
+		g.Printf(", %s", arg)
+	}
+	g.Printf(")\n}\n")
+}
+
+// ownFormat returns the format and the arguments, each preceded by a comma, of the message of a
+// template without the cause. Templates with optional fields use the errMsg method.
+func ownFormat(template ParsedTemplate) (string, string) {
+	if hasOpt(template) {
+		return "%s", ", e.errMsg()"
+	}
+	return template.fmt, fieldArgs(template)
+}
+
+// hasOpt reports whether a template has optional fields.
+func hasOpt(template ParsedTemplate) bool {
+	for _, f := range template.fields {
+		if f.opt {
+			return true
+		}
+	}
+	return false
+}
+
+// generateOptMsg generates the body of a method building the message of a template with
+// optional fields, appending each of them with its tied literal only when non-zero.
+func (g *Generator) generateOptMsg(template ParsedTemplate) {
+	g.Printf("\tvar s string\n")
+	var format string
+	var args []string
+	flush := func() {
+		switch {
+		case format == "":
+		case len(args) == 0:
+			g.Printf("\ts += %q\n", strings.ReplaceAll(format, "%%", "%"))
+		default:
+			g.Printf("\ts += fmt.Sprintf(%q, %s)\n", format, strings.Join(args, ", "))
+		}
+		format, args = "", nil
+	}
+	for i, f := range template.fields {
+		format += template.segments[i]
+		if !f.opt {
+			format += f.fmt
+			args = append(args, "e."+f.val)
+			continue
+		}
+		flush()
+		g.Printf("\tif %s {\n\t\ts += fmt.Sprintf(%q, e.%s)\n\t}\n", nonZero(f), f.tied+f.fmt, f.val)
+	}
+	format += template.segments[len(template.fields)]
+	flush()
+	g.Printf("\treturn s\n}\n")
+}
+
+// nonZero returns the condition under which an optional field is rendered.
+func nonZero(f Field) string {
+	v := "e." + f.val
+	switch {
+	case strings.HasPrefix(f.typ, "..."), strings.HasPrefix(f.typ, "[]"), strings.HasPrefix(f.typ, "map["):
+		return "len(" + v + ") > 0"
+	case strings.HasPrefix(f.typ, "*"), f.typ == "error":
+		return v + " != nil"
+	case f.typ == "string":
+		return v + ` != ""`
+	case f.typ == "bool":
+		return v
+	case numericTypes[f.typ]:
+		return v + " != 0"
+	}
+	return v + " != *new(" + f.typ + ")"
+}
+
+// numericTypes are the predeclared numeric types, whose zero value is 0.
+var numericTypes = map[string]bool{
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+	"float32": true, "float64": true, "complex64": true, "complex128": true, "byte": true, "rune": true,
+}
+
+// fieldArgs returns the arguments to format the fields of a template, each preceded by a comma.
+func fieldArgs(template ParsedTemplate) string {
+	var b strings.Builder
+	for _, f := range template.fields {
+		b.WriteString(", e.")
+		b.WriteString(f.val)
+	}
+	return b.String()
+}
+
+// footer generates the declarations that depend on all the generated errors.
+func (g *Generator) footer() {
+	if g.registry {
+		// Generate slice with all the errors.
+		varName := "all" + g.typeName
+		if g.makePub {
+			varName = "All" + g.typeName
+		}
+		g.Printf("var %s = []%s{%s}\n\n", varName, g.typeName, strings.Join(g.generated, ", "))
+	}
+	if g.ctorMap {
+		g.generateCtorMap()
+	}
+	if g.codeEnum {
+		g.generateCodeEnum()
+	}
+}
+
+// codeTypeName returns the name of the enum of the error codes, with -code-enum.
+func (g *Generator) codeTypeName() string { return g.typeName + "Code" }
+
+// generateCodeEnum generates the enum of the error codes and a constant for each error.
+func (g *Generator) generateCodeEnum() {
+	codes, _ := g.assignCodes() // Checked by checkSpecs.
+	g.Printf("type %s int\n\nconst (\n", g.codeTypeName())
+	for _, spec := range g.specs {
+		g.Printf("\t%sCode %s = %d\n", spec.name, g.codeTypeName(), codes[spec.name])
+	}
+	g.Printf(")\n\n")
+}
+
+// assignCodes returns the code of each error: the one given with the code: directive, or the
+// first code from -code-base not taken yet. Codes are assigned in the order of the names of
+// the errors, so that they are stable across runs.
+func (g *Generator) assignCodes() (map[string]int, error) {
+	codes := make(map[string]int, len(g.specs))
+	taken := make(map[int]string)
+	names := make([]string, 0, len(g.specs))
+	for _, spec := range g.specs {
+		names = append(names, spec.name)
+		code := spec.parsed.code
+		if code < 0 {
+			continue
+		}
+		if other, ok := taken[code]; ok {
+			return nil, fmt.Errorf("%s and %s both have code %d", other, spec.name, code)
+		}
+		taken[code] = spec.name
+		codes[spec.name] = code
+	}
+	sort.Strings(names)
+	next := g.codeBase
+	for _, name := range names {
+		if _, ok := codes[name]; ok {
+			continue
+		}
+		for taken[next] != "" {
+			next++
+		}
+		taken[next] = name
+		codes[name] = next
+	}
+	return codes, nil
+}
+
+// generateCtorMap generates a map from each error constant to a function calling its
+// constructor with positional arguments, checking their number and types.
+func (g *Generator) generateCtorMap() {
+	runes := []rune(g.typeName)
+	varName := string(unicode.ToLower(runes[0])) + string(runes[1:]) + "Ctors"
+	if g.makePub {
+		varName = string(unicode.ToUpper(runes[0])) + string(runes[1:]) + "Ctors"
+	}
+	g.Printf("var %s = map[%s]func(args ...interface{}) (error, error){\n", varName, g.typeName)
+	for _, spec := range g.specs {
+		params := g.ctorParams(spec.parsed)
+		variadic := len(params) > 0 && strings.HasPrefix(params[len(params)-1].typ, "...")
+		fixed := len(params)
+		if variadic {
+			fixed--
+		}
+		g.Printf("\t%s: func(args ...interface{}) (error, error) {\n", spec.name)
+		if variadic {
+			g.Printf("\t\tif len(args) < %d {\n", fixed)
+			g.Printf("\t\t\treturn nil, fmt.Errorf(\"%s: got %%d arguments, expected at least %d\", len(args))\n",
+				spec.name, fixed)
+		} else {
+			g.Printf("\t\tif len(args) != %d {\n", fixed)
+			g.Printf("\t\t\treturn nil, fmt.Errorf(\"%s: got %%d arguments, expected %d\", len(args))\n",
+				spec.name, fixed)
+		}
+		g.Printf("\t\t}\n")
+		args := make([]string, 0, len(params))
+		for i, p := range params[:fixed] {
+			arg := fmt.Sprintf("a%d", i)
+			g.Printf("\t\t%s, ok := args[%d].(%s)\n", arg, i, p.typ)
+			if p.typ == "error" {
+				// A nil cause is allowed.
+				g.Printf("\t\tif !ok && args[%d] != nil {\n", i)
+			} else {
+				g.Printf("\t\tif !ok {\n")
+			}
+			g.Printf("\t\t\treturn nil, fmt.Errorf(\"%s: argument %d has type %%T, expected %s\", args[%d])\n\t\t}\n",
+				spec.name, i, p.typ, i)
+			args = append(args, arg)
+		}
+		if variadic {
+			arg, elem := fmt.Sprintf("a%d", fixed), strings.TrimPrefix(params[fixed].typ, "...")
+			g.Printf("\t\t%s := make([]%s, 0, len(args)-%d)\n", arg, elem, fixed)
+			g.Printf("\t\tfor i, arg := range args[%d:] {\n\t\t\tv, ok := arg.(%s)\n", fixed, elem)
+			if elem == "error" {
+				g.Printf("\t\t\tif !ok && arg != nil {\n")
+			} else {
+				g.Printf("\t\t\tif !ok {\n")
+			}
+			g.Printf("\t\t\t\treturn nil, fmt.Errorf(\"%s: argument %%d has type %%T, expected %s\", %d+i, arg)\n",
+				spec.name, elem, fixed)
+			g.Printf("\t\t\t}\n\t\t\t%s = append(%s, v)\n\t\t}\n", arg, arg)
+			args = append(args, arg+"...")
+		}
+		g.Printf("\t\treturn %s(%s), nil\n\t},\n", g.ctorName(g.structName(spec.name)), strings.Join(args, ", "))
+	}
+	g.Printf("}\n\n")
+}
+
+func (g *Generator) structName(specName string) string {
+	if len(g.specSuffix) > 0 {
+		runes := []rune(specName)
+		specName = string(runes[0]) + strings.TrimSuffix(string(runes[1:]), g.specSuffix)
+	}
+	if g.structPfx != "" {
+		// The casing applies to the first rune of the whole name.
+		specName = g.structPfx + strings.Title(specName)
+	}
+	var b strings.Builder
+	runes := []rune(specName)
+	if g.makePub {
+		b.WriteRune(unicode.ToUpper(runes[0]))
+	} else {
+		b.WriteRune(unicode.ToLower(runes[0]))
+	}
+	b.WriteString(string(runes[1:]))
+	return b.String()
+}
+
+type ParsedTemplate struct {
+	wrap   WrapMode
+	fields []Field // fields in order of appearance, one per placeholder
+	// roots are the distinct root fields, in order of first appearance, which are the fields of
+	// the struct and the parameters of the constructor.
+	roots   []Field
+	fmt     string
+	class   string // client or server error class, if any
+	dynamic string // name of the function computing the message, if any
+	// causeIdx is the position of the cause among the fields when placed inline with a
+	// {{cause}} placeholder or a field of type error, -1 when it is appended to the message.
+	causeIdx int
+	// causeName is the name of the field of type error holding the cause, if any, which is
+	// the constructor parameter at causePos among the roots.
+	causeName string
+	causePos  int
+	exitCode  int    // process exit code of the error, -1 if not given
+	code      int    // code of the error in the -code-enum enum, -1 if not given
+	severity  string // severity level of the error, if any
+	// net is set for errors implementing net.Error, whose Temporary and Timeout methods
+	// return temporary and timeout.
+	net, temporary, timeout bool
+	// deprecated is set for errors that should not be used anymore, replacement is the name
+	// of the error to use instead, if any.
+	deprecated  bool
+	replacement string
+	// segments are the literal parts of the message around the fields, one more than them.
+	segments []string
+}
+
+// String returns a readable representation of the parsed template, for debugging.
+func (t ParsedTemplate) String() string {
+	fields := make([]string, len(t.fields))
+	for i, f := range t.fields {
+		fields[i] = f.String()
+	}
+	s := fmt.Sprintf("wrap=%s fmt=%q fields=[%s]", t.wrap, t.fmt, strings.Join(fields, ", "))
+	if t.class != "" {
+		s += " class=" + t.class
+	}
+	if t.dynamic != "" {
+		s += " dynamic=" + t.dynamic
+	}
+	if t.causeName != "" {
+		s += fmt.Sprintf(" cause=%d via %s", t.causeIdx, t.causeName)
+	} else if t.causeIdx >= 0 {
+		s += fmt.Sprintf(" cause=%d", t.causeIdx)
+	}
+	if t.exitCode >= 0 {
+		s += fmt.Sprintf(" exit=%d", t.exitCode)
+	}
+	if t.code >= 0 {
+		s += fmt.Sprintf(" code=%d", t.code)
+	}
+	if t.severity != "" {
+		s += " sev=" + t.severity
+	}
+	if t.net {
+		s += fmt.Sprintf(" net=temp:%t,timeout:%t", t.temporary, t.timeout)
+	}
+	if t.replacement != "" {
+		s += " deprecated=" + t.replacement
+	} else if t.deprecated {
+		s += " deprecated"
+	}
+	return s
+}
+
+type WrapMode int
+
+const (
 	OptWrap WrapMode = iota
 	NoWrap
 	MustWrap
+	MultiWrap
 )
 
+func (m WrapMode) String() string {
+	switch m {
+	case OptWrap:
+		return "optwrap"
+	case NoWrap:
+		return "nowrap"
+	case MustWrap:
+		return "wrap"
+	case MultiWrap:
+		return "multiwrap"
+	}
+	return fmt.Sprintf("WrapMode(%d)", int(m))
+}
+
 // Field represents a field from a parsed template.
 type Field struct {
 	name string // name of the field
 	typ  string // type of the field
 	fmt  string // format verb for the field
 	val  string // accessor to use when formatting (e.g. name.Field)
+	re   string // pattern the value is validated against by the constructor, if any
+	opt  bool   // whether the field is rendered only when non-zero
+	tied string // literal text preceding an optional field, omitted together with it
 }
 
-func parseTemplate(template string) ParsedTemplate {
-	wrap := OptWrap
-	switch {
-	case strings.HasPrefix(template, "wrap:"):
-		wrap = MustWrap
-		template = strings.TrimPrefix(template, "wrap:")
-	case strings.HasPrefix(template, "nowrap:"):
-		wrap = NoWrap
-		template = strings.TrimPrefix(template, "nowrap:")
-	}
-	matches := tmplRE.FindAllStringSubmatch(template, -1)
+// String returns a readable representation of the field, for debugging.
+func (f Field) String() string {
+	s := fmt.Sprintf("%s %s %s", f.name, f.typ, f.fmt)
+	if f.val != f.name {
+		s += " via " + f.val
+	}
+	if f.re != "" {
+		s += " /" + f.re + "/"
+	}
+	if f.opt {
+		s += fmt.Sprintf(" opt %q", f.tied)
+	}
+	return s
+}
+
+// severities are the levels accepted by the sev: directive.
+var severities = map[string]bool{"debug": true, "info": true, "warn": true, "error": true, "fatal": true}
+
+// parseTemplate parses the directives and the fields of a template, returning an error when
+// it is malformed.
+func parseTemplate(template string) (ParsedTemplate, error) {
+	t := ParsedTemplate{wrap: OptWrap, causeIdx: -1, exitCode: -1, code: -1}
+directives:
+	for {
+		switch {
+		case cutDirective(&template, "wrap:"):
+			t.wrap = MustWrap
+		case cutDirective(&template, "nowrap:"):
+			t.wrap = NoWrap
+		case cutDirective(&template, "multiwrap:"):
+			t.wrap = MultiWrap
+		case cutDirective(&template, "client:"):
+			t.class = "client"
+		case cutDirective(&template, "server:"):
+			t.class = "server"
+		case cutDirective(&template, "dynamic:"):
+			t.dynamic = cutDirectiveValue(&template)
+			if !token.IsIdentifier(t.dynamic) {
+				return t, fmt.Errorf("invalid function name %q in dynamic directive", t.dynamic)
+			}
+		case cutDirective(&template, "exit:"):
+			value := cutDirectiveValue(&template)
+			code, err := strconv.Atoi(value)
+			if err != nil || code < 0 || code > 255 {
+				return t, fmt.Errorf("invalid exit code %q in exit directive, expected 0-255", value)
+			}
+			t.exitCode = code
+		case cutDirective(&template, "code:"):
+			value := cutDirectiveValue(&template)
+			code, err := strconv.Atoi(value)
+			if err != nil || code < 0 {
+				return t, fmt.Errorf("invalid code %q in code directive, expected a non-negative integer", value)
+			}
+			t.code = code
+		case cutDirective(&template, "sev:"):
+			t.severity = cutDirectiveValue(&template)
+			if !severities[t.severity] {
+				return t, fmt.Errorf("invalid severity %q in sev directive, expected one of "+
+					"debug, info, warn, error or fatal", t.severity)
+			}
+		case cutDirective(&template, "net:"):
+			t.net = true
+			value := cutDirectiveValue(&template)
+			for _, flag := range strings.Split(value, ",") {
+				switch flag {
+				case "temp":
+					t.temporary = true
+				case "timeout":
+					t.timeout = true
+				default:
+					return t, fmt.Errorf("invalid flag %q in net directive, expected temp or timeout", flag)
+				}
+			}
+		case cutDirective(&template, "deprecated:"):
+			t.deprecated = true
+			if cutDirective(&template, "use ") {
+				t.replacement = cutDirectiveValue(&template)
+				if !token.IsIdentifier(t.replacement) {
+					return t, fmt.Errorf("invalid error name %q in deprecated directive", t.replacement)
+				}
+			}
+		default:
+			break directives
+		}
+	}
+	// Literal text is passed to fmt, escape its % signs, leaving the verbs of the fields alone.
+	var escaped strings.Builder
+	last := 0
+	for _, loc := range tmplRE.FindAllStringIndex(template, -1) {
+		if err := checkLiteral(template[last:loc[0]]); err != nil {
+			return t, fmt.Errorf("template %q: %w", template, err)
+		}
+		escaped.WriteString(escapePercent(template[last:loc[0]]))
+		escaped.WriteString(template[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	if err := checkLiteral(template[last:]); err != nil {
+		return t, fmt.Errorf("template %q: %w", template, err)
+	}
+	escaped.WriteString(escapePercent(template[last:]))
+	template = escaped.String()
+	if n := strings.Count(template, causeToken); n > 1 {
+		return t, fmt.Errorf("template %q has %d %s placeholders, expected at most one",
+			template, n, causeToken)
+	} else if n == 1 {
+		if t.wrap == NoWrap {
+			return t, fmt.Errorf("template %q has a %s placeholder but does not wrap", template, causeToken)
+		}
+		// The cause comes after the fields preceding its placeholder.
+		t.causeIdx = len(tmplRE.FindAllStringIndex(template[:strings.Index(template, causeToken)], -1))
+		template = strings.Replace(template, causeToken, "%v", 1)
+	}
+	matches := tmplRE.FindAllStringSubmatchIndex(template, -1)
 	fields := make([]Field, 0, len(matches))
 	tmplStr := template
-	for _, match := range matches {
-		fExpr, fType, fFmt := match[1], match[2], match[3]
+	last = 0
+	for _, idx := range matches {
+		match := make([]string, 6)
+		for i := range match {
+			if idx[2*i] >= 0 {
+				match[i] = template[idx[2*i]:idx[2*i+1]]
+			}
+		}
+		t.segments = append(t.segments, template[last:idx[0]])
+		last = idx[1]
+		fExpr, fOpt, fType, fFmt, fRE := match[1], match[2] != "", match[3], match[4], match[5]
+		if root := exprRootName(fExpr); token.IsKeyword(root) {
+			return t, fmt.Errorf("field %s of template %q is a Go keyword, rename it or use -rename-reserved",
+				root, template)
+		}
 		nameAST, err := parser.ParseExpr(fExpr)
 		if err != nil {
-			log.Fatal(err)
+			return t, fmt.Errorf("field expression %q: %w", fExpr, err)
 		}
 		fNameIdent := findExprRoot(nameAST)
 		if fNameIdent == nil {
-			log.Fatalf("Could not find root node of expression %q", fExpr)
+			return t, fmt.Errorf("could not find root node of expression %q", fExpr)
+		}
+		if hasCallArgs(nameAST) {
+			return t, fmt.Errorf("field expression %q calls a function with arguments", fExpr)
+		}
+		if strings.HasSuffix(fFmt, "w") {
+			if fType != "error" {
+				return t, fmt.Errorf("field %s has verb %s but type %s, expected error", fNameIdent.Name, fFmt, fType)
+			}
+			// The field is the cause, unwrapped by the generated Unwrap method: format it like
+			// fmt.Errorf does, as with %v.
+			fFmt = strings.TrimSuffix(fFmt, "w") + "v"
+		}
+		if fRE != "" {
+			if fType != "string" {
+				return t, fmt.Errorf("field %s has a pattern but type %s, expected string", fNameIdent.Name, fType)
+			}
+			if _, err := regexp.Compile(fRE); err != nil {
+				return t, fmt.Errorf("pattern of field %s: %w", fNameIdent.Name, err)
+			}
+		}
+		var tied string
+		if fOpt {
+			if t.causeIdx >= 0 {
+				return t, fmt.Errorf("optional field %s cannot be used with a %s placeholder in template %q",
+					fNameIdent.Name, causeToken, template)
+			}
+			// The preceding space-delimited token is omitted together with the field.
+			seg := t.segments[len(t.segments)-1]
+			i := strings.LastIndexByte(strings.TrimRight(seg, " "), ' ')
+			if i < 0 {
+				i = 0
+			}
+			t.segments[len(t.segments)-1], tied = seg[:i], seg[i:]
 		}
 		tmplStr = strings.Replace(tmplStr, match[0], fFmt, 1)
 		fields = append(fields, Field{
-			//nolint:staticcheck
-			//lint:ignore SA5011 not nil as it's checked above
 			name: fNameIdent.Name,
 			typ:  fType,
 			fmt:  fFmt,
 			val:  fExpr,
+			re:   fRE,
+			opt:  fOpt,
+			tied: tied,
 		})
 	}
-	return ParsedTemplate{wrap, fields, tmplStr}
+	t.segments = append(t.segments, template[last:])
+	fields, err := t.cutCauseField(fields, template)
+	if err != nil {
+		return t, err
+	}
+	roots, err := rootFields(fields)
+	if err != nil {
+		return t, fmt.Errorf("template %q: %w", template, err)
+	}
+	if t.causeName != "" {
+		// The cause parameter comes after the roots appearing before its placeholder.
+		seen := make(map[string]bool)
+		for _, f := range fields[:t.causeIdx] {
+			seen[f.name] = true
+		}
+		t.causePos = len(seen)
+		if n := len(roots); t.causePos == n && n > 0 && strings.HasPrefix(roots[n-1].typ, "...") {
+			return t, fmt.Errorf("variadic field %s must be the last field of template %q", roots[n-1].name, template)
+		}
+	}
+	for i, f := range roots {
+		if !strings.HasPrefix(f.typ, "...") {
+			continue
+		}
+		if i < len(roots)-1 {
+			return t, fmt.Errorf("variadic field %s must be the last field of template %q", f.name, template)
+		}
+		if t.wrap == MultiWrap {
+			return t, fmt.Errorf("variadic field %s cannot be used with multiwrap: in template %q", f.name, template)
+		}
+	}
+	t.fields = fields
+	t.roots = roots
+	t.fmt = tmplStr
+	return t, nil
+}
+
+// exprRootName returns the identifier at the start of a field expression.
+func exprRootName(expr string) string {
+	if i := strings.IndexAny(expr, ".[("); i >= 0 {
+		return expr[:i]
+	}
+	return expr
+}
+
+// renameReserved renames the fields of a template named after Go keywords, which cannot be
+// used as struct fields and parameters, by appending an underscore.
+func renameReserved(template string) string {
+	return tmplRE.ReplaceAllStringFunc(template, func(placeholder string) string {
+		expr := strings.TrimPrefix(placeholder, "{{")
+		if root := exprRootName(expr[:strings.IndexByte(expr, ' ')]); token.IsKeyword(root) {
+			return "{{" + root + "_" + strings.TrimPrefix(expr, root)
+		}
+		return placeholder
+	})
+}
+
+// cutCauseField removes the field of type error from the fields, making it the cause of the
+// error, placed inline like with a {{cause}} placeholder.
+func (t *ParsedTemplate) cutCauseField(fields []Field, template string) ([]Field, error) {
+	idx := -1
+	for i, f := range fields {
+		if f.typ != "error" {
+			continue
+		}
+		switch {
+		case idx >= 0:
+			return nil, fmt.Errorf("template %q has more than one field of type error", template)
+		case t.causeIdx >= 0:
+			return nil, fmt.Errorf("template %q has both a field of type error and a %s placeholder",
+				template, causeToken)
+		case t.wrap == NoWrap || t.wrap == MultiWrap:
+			return nil, fmt.Errorf("field %s of type error cannot be the cause of a %s error", f.name, t.wrap)
+		case f.val != f.name || f.re != "" || f.opt:
+			return nil, fmt.Errorf("field %s of type error has to be a plain field", f.name)
+		}
+		idx = i
+	}
+	if idx < 0 {
+		return fields, nil
+	}
+	t.wrap = MustWrap
+	t.causeIdx = idx
+	t.causeName = fields[idx].name
+	// Keep a segment more than the fields, joining the ones around the cause.
+	t.segments[idx] += fields[idx].fmt + t.segments[idx+1]
+	t.segments = append(t.segments[:idx+1], t.segments[idx+2:]...)
+	for i, f := range fields {
+		if i != idx && f.name == t.causeName {
+			return nil, fmt.Errorf("field %s of type error cannot be used more than once", f.name)
+		} else if f.opt {
+			return nil, fmt.Errorf("optional field %s cannot be used with field %s of type error", f.name, t.causeName)
+		}
+	}
+	return append(fields[:idx:idx], fields[idx+1:]...), nil
+}
+
+// checkLiteral verifies that literal text does not hold a placeholder that failed to match,
+// e.g. because of an unsupported verb, which would otherwise be printed as is.
+func checkLiteral(lit string) error {
+	lit = strings.ReplaceAll(lit, causeToken, "")
+	if i := strings.Index(lit, "{{"); i >= 0 {
+		return fmt.Errorf("malformed placeholder %q", lit[i:])
+	}
+	return nil
+}
+
+// checkVerbs checks that the number of verbs of the message passed to fmt equals the number of
+// fields, plus one for a cause placed in the message, so that each argument meets its verb.
+// Literal % signs are escaped as %% and are not counted.
+func (t ParsedTemplate) checkVerbs() error {
+	expected := len(t.fields)
+	if t.causeIdx >= 0 {
+		expected++
+	}
+	if n := countVerbs(t.fmt); n != expected {
+		return fmt.Errorf("message %q has %d verbs, expected %d", t.fmt, n, expected)
+	}
+	return nil
+}
+
+// countVerbs counts the formatting verbs in a format string, ignoring escaped percent signs.
+func countVerbs(format string) int {
+	n := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		if i+1 < len(format) && format[i+1] == '%' {
+			i++
+			continue
+		}
+		n++
+	}
+	return n
+}
+
+// escapePercent escapes the % signs of literal text for fmt. A %% already escaped is kept as a
+// single literal %.
+func escapePercent(lit string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(lit, "%%", "%"), "%", "%%")
+}
+
+// rootFields returns the distinct root fields, in order of first appearance. Placeholders
+// sharing a root have to agree on its type and pattern.
+func rootFields(fields []Field) ([]Field, error) {
+	roots := make([]Field, 0, len(fields))
+	seen := make(map[string]int, len(fields))
+	for _, f := range fields {
+		i, ok := seen[f.name]
+		if !ok {
+			seen[f.name] = len(roots)
+			roots = append(roots, f)
+			continue
+		}
+		if r := roots[i]; r.typ != f.typ {
+			return nil, fmt.Errorf("field %s is declared as %s and as %s", f.name, r.typ, f.typ)
+		} else if r.re != f.re {
+			return nil, fmt.Errorf("field %s is declared with different patterns", f.name)
+		}
+	}
+	return roots, nil
+}
+
+// cutDirectiveValue removes the value of a directive from the template and returns it. The value
+// extends up to the first space, which is removed as well.
+func cutDirectiveValue(template *string) string {
+	value := *template
+	*template = ""
+	if i := strings.IndexByte(value, ' '); i >= 0 {
+		value, *template = value[:i], value[i+1:]
+	}
+	return value
+}
+
+// cutDirective removes the directive prefix from the template, reporting whether it was found.
+func cutDirective(template *string, directive string) bool {
+	if !strings.HasPrefix(*template, directive) {
+		return false
+	}
+	*template = strings.TrimPrefix(*template, directive)
+	return true
 }
 
 func findExprRoot(node ast.Expr) *ast.Ident {
@@ -437,22 +3508,46 @@ func findExprRoot(node ast.Expr) *ast.Ident {
 			node = n.X
 		case *ast.IndexExpr:
 			node = n.X
+		case *ast.CallExpr:
+			node = n.Fun
 		case *ast.Ident:
 			return n
 		default:
-			return nil
+			var ok bool
+			if node, ok = indexListX(node); !ok {
+				return nil
+			}
 		}
 	}
 }
 
-func (g *Generator) format() []byte {
-	src, err := format.Source(g.buf.Bytes())
+// hasCallArgs reports whether the expression has calls taking arguments, only calls to
+// zero-arg methods are allowed in field expressions.
+func hasCallArgs(node ast.Expr) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		if c, ok := n.(*ast.CallExpr); ok && len(c.Args) > 0 {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+func (g *Generator) format() ([]byte, error) {
+	var src []byte
+	var err error
+	if g.goimports {
+		src, err = imports.Process("", g.buf.Bytes(), nil)
+	} else {
+		src, err = format.Source(g.buf.Bytes())
+	}
 	if err != nil {
-		log.Printf("warning: failed to format generated code: %v\n", err)
-		log.Printf("warning: try to compile the output to check the error\n")
 		if len(src) == 0 {
-			log.Fatalf("format produced empty output\n%s\n", g.buf.String())
+			return nil, fmt.Errorf("format produced empty output: %w\n%s", err, g.buf.String())
 		}
+		log.Printf("warning: failed to format generated code: %v\n", err)
+		log.Printf("warning: try to compile the output to check the error\n")
 	}
-	return src
+	return src, nil
 }
@@ -0,0 +1,856 @@
+// (c) Copyright 2021, Gorror Authors.
+//
+// Licensed under the terms of the GNU GPL License version 3.
+
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+)
+
+func TestParsedTemplateString(t *testing.T) {
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{"some error", `wrap=optwrap fmt="some error" fields=[]`},
+		{"wrap:some error", `wrap=wrap fmt="some error" fields=[]`},
+		{"nowrap:some error", `wrap=nowrap fmt="some error" fields=[]`},
+		{
+			"failed to {{op string %s}} {{file string %q}}",
+			`wrap=optwrap fmt="failed to %s %q" fields=[op string %s, file string %q]`,
+		},
+		{
+			"nowrap:failed for {{c.Field[0] MyStruct %s}} ({{code uint %d}})",
+			`wrap=nowrap fmt="failed for %s (%d)" fields=[c MyStruct %s via c.Field[0], code uint %d]`,
+		},
+		{
+			"wrap:{{op string %s}}: {{cause}}",
+			`wrap=wrap fmt="%s: %v" fields=[op string %s] cause=1`,
+		},
+		{"nowrap:disk {{pct int %d}}% full", `wrap=nowrap fmt="disk %d%% full" fields=[pct int %d]`},
+		{"100%% failed, 50% done", `wrap=optwrap fmt="100%% failed, 50%% done" fields=[]`},
+		{
+			"{{op string %s}}: {{err error %v}} on {{file string %q}}",
+			`wrap=wrap fmt="%s: %v on %q" fields=[op string %s, file string %q] cause=1 via err`,
+		},
+		{"failed: {{inner error %+w}}", `wrap=wrap fmt="failed: %+v" fields=[] cause=0 via inner`},
+		{"exit:3 nowrap:usage error", `wrap=nowrap fmt="usage error" fields=[] exit=3`},
+		{"code:1001 nowrap:some error", `wrap=nowrap fmt="some error" fields=[] code=1001`},
+		{"wrap:sev:warn some error", `wrap=wrap fmt="some error" fields=[] sev=warn`},
+		{"net:timeout,temp some error", `wrap=optwrap fmt="some error" fields=[] net=temp:true,timeout:true`},
+		{
+			"nowrap:{{op string %-10s}} took {{ms int % 5d}}ms ({{ratio float64 %+.2f}})",
+			`wrap=nowrap fmt="%-10s took % 5dms (%+.2f)" fields=[op string %-10s, ms int % 5d, ratio float64 %+.2f]`,
+		},
+		{"nowrap:café {{café string %s}}ü", `wrap=nowrap fmt="café %sü" fields=[café string %s]`},
+		{
+			"dial {{addr string %s}} attempt {{n opt int %d}}",
+			`wrap=optwrap fmt="dial %s attempt %d" fields=[addr string %s, n int %d opt " attempt "]`,
+		},
+	}
+	for _, test := range tests {
+		parsed, err := parseTemplate(test.template)
+		if err != nil {
+			t.Errorf("%q: %s", test.template, err)
+			continue
+		}
+		if got := parsed.String(); got != test.expected {
+			t.Errorf("%q: got %q, expected %q", test.template, got, test.expected)
+		}
+	}
+}
+
+func TestParseTemplateErrors(t *testing.T) {
+	for _, template := range []string{
+		"dynamic:1fn message",
+		"wrap:{{cause}} and {{cause}}",
+		"nowrap:failed: {{cause}}",
+		"failed on {{0.x string %s}}",
+		"failed on {{1[0] string %s}}",
+		"failed on {{files ...string %v}} and {{dir string %s}}",
+		"multiwrap:failed on {{files ...string %v}}",
+		"exit:256 failed",
+		"exit:code failed",
+		"code:-1 failed",
+		"sev:critical failed",
+		"net:temporary failed",
+		"net: failed",
+		"failed on {{a.X Point %d}} and {{a.Y Line %d}}",
+		"failed on {{a string %s /^a$/}} and {{a string %s}}",
+		"code:E42 failed",
+		"invalid {{code int %d /^[0-9]+$/}}",
+		"invalid {{code string %s /[a-z/}}",
+		"deprecated:use 1Err failed",
+		"failed on {{c.Get(0) Cache %v}}",
+		"failed on {{n int %[1]d}}",
+		"failed on {{n int %*d}}",
+		"failed on {{n int %dd}}",
+		"wrap:{{cause}} on {{n int}}",
+		"nowrap:failed: {{err error %v}}",
+		"failed: {{a error %v}} and {{b error %v}}",
+		"wrap:failed: {{err error %v}} {{cause}}",
+		"failed on {{files ...string %v}}: {{err error %v}}",
+		"failed {{n opt int %d}}: {{err error %v}}",
+		"failed on {{c.Items()[c.Index(1)] Cache %v}}",
+		"wrap:failed after {{n opt int %d}}: {{cause}}",
+		"invalid {{type string %s}}",
+		"failed: {{inner string %w}}",
+		"failed: {{a error %w}} and {{b error %w}}",
+		"nowrap:failed: {{inner error %w}}",
+	} {
+		if _, err := parseTemplate(template); err == nil {
+			t.Errorf("%q: expected an error", template)
+		}
+	}
+}
+
+func TestRenameReserved(t *testing.T) {
+	for template, expected := range map[string]string{
+		"invalid {{type string %s}}":                       "invalid {{type_ string %s}}",
+		"bad {{range.Lo int %d}} in {{len int %d}}":        "bad {{range_.Lo int %d}} in {{len int %d}}",
+		"nowrap:{{go opt int %d}} and {{types string %s}}": "nowrap:{{go_ opt int %d}} and {{types string %s}}",
+	} {
+		if got := renameReserved(template); got != expected {
+			t.Errorf("%q: got %q, expected %q", template, got, expected)
+		}
+		if _, err := parseTemplate(renameReserved(template)); err != nil {
+			t.Errorf("%q: %v", template, err)
+		}
+	}
+}
+
+func TestResolveNamed(t *testing.T) {
+	for _, test := range []struct {
+		template, decls, expected, err string
+	}{
+		{"failed to {{.op}} {{.file}}", " op string %s,  file string %q", "failed to {{op string %s}} {{file string %q}}", ""},
+		{"{{.n}} of {{.n}} {{x int %d}}", "n opt int %d", "{{n opt int %d}} of {{n opt int %d}} {{x int %d}}", ""},
+		{"failed to {{.op}}", "", "", `field op of template "failed to {{.op}}" is not declared in a gorror-fields: comment`},
+		{"failed", "op string %s", "", `field op is declared but not used in template "failed"`},
+		{"{{.op}}", "op string %s, op int %d", "", "field op is declared more than once"},
+		{"{{.op}}", "op string", "", `invalid field declaration "op string", expected name type verb`},
+	} {
+		got, err := resolveNamed(test.template, test.decls)
+		switch {
+		case test.err != "" && (err == nil || err.Error() != test.err):
+			t.Errorf("%q: got error %v, expected %q", test.template, err, test.err)
+		case test.err == "" && err != nil:
+			t.Errorf("%q: unexpected error: %v", test.template, err)
+		case got != test.expected:
+			t.Errorf("%q: got %q, expected %q", test.template, got, test.expected)
+		}
+	}
+}
+
+func TestCheckVerbs(t *testing.T) {
+	for _, template := range []string{
+		"100% done {{n int %d}}",
+		"100%% done {{n int %d}}",
+		"nowrap:disk {{pct int %d}}% full",
+		"failed on %s {{file string %s}}",
+		"wrap:{{op string %s}}: {{cause}} on {{file string %q}}",
+		"command {{cmd string %s}} failed: {{reason error %v}}",
+		"failed {{n opt int %d}} and {{n opt int %d}}",
+	} {
+		if err := mustParse(t, template).checkVerbs(); err != nil {
+			t.Errorf("%q: %v", template, err)
+		}
+	}
+
+	// A stray verb, e.g. a literal % left unescaped, takes the argument of a field.
+	stray := ParsedTemplate{fmt: "100% failed on %s", fields: []Field{{name: "file"}}, causeIdx: -1}
+	expected := `message "100% failed on %s" has 2 verbs, expected 1`
+	if err := stray.checkVerbs(); err == nil || err.Error() != expected {
+		t.Errorf("got error %v, expected %q", err, expected)
+	}
+}
+
+func TestEscapedPercentNotReported(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "percent.go")
+	src := `package test
+type Err string
+const ErrFull = Err("nowrap:disk {{pct int %d}}% full")`
+	if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+
+	g := Generator{typeName: "Err", strict: true}
+	if err := g.loadPackage([]string{file}); err != nil {
+		t.Fatalf("literal %% escaped by gorror reported: %v", err)
+	}
+	if out.Len() > 0 {
+		t.Errorf("expected no warning, got:\n%s", out.String())
+	}
+	if len(g.specs) != 1 {
+		t.Errorf("got %d errors, expected 1", len(g.specs))
+	}
+}
+
+func TestFieldString(t *testing.T) {
+	f := Field{name: "file", typ: "string", fmt: "%q", val: "file"}
+	if got, expected := f.String(), "file string %q"; got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+	f = Field{name: "c", typ: "*MyStruct", fmt: "%v", val: "c.Name"}
+	if got, expected := f.String(), "c *MyStruct %v via c.Name"; got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+}
+
+func TestVerbose(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "verbose.go")
+	src := `package test
+type Err string
+type Code int
+const (
+	ErrOpen = Err("failed to open {{file string %q}}")
+	ErrRead = Err("nowrap:failed to read")
+	ErrCode = Code(1)
+	ErrUntyped = "failed"
+)
+const ErrValue Err = Err("failed" + "!")`
+	if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+
+	g := Generator{typeName: "Err", verbose: true}
+	if err := g.loadPackage([]string{file}); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`found ErrOpen: wrap=optwrap fmt="failed to open %q" fields=[file string %q]`,
+		`found ErrRead: wrap=nowrap fmt="failed to read" fields=[]`,
+		`skip ErrCode: of type Code`,
+		`skip ErrUntyped: untyped or of an unknown type`,
+		`skip ErrValue: no string value nor //gorror: comment`,
+		`verbose.go: 2 const declarations, 2 errors of type Err`,
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("verbose output does not contain %q:\n%s", expected, out.String())
+		}
+	}
+
+	out.Reset()
+	g = Generator{typeName: "Err", verbose: true, quiet: true}
+	if err := g.loadPackage([]string{file}); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() > 0 {
+		t.Errorf("expected no output in quiet mode, got:\n%s", out.String())
+	}
+}
+
+func TestWrapTypeWarning(t *testing.T) {
+	dir := t.TempDir()
+	src := `package test
+type Err string
+const ErrOpen = Err("failed to open file")
+type _errWrap struct{}`
+	if err := os.WriteFile(filepath.Join(dir, "wrap.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Previously generated files are expected to declare the type.
+	generated := generatedHeader + "\n\npackage test\n\ntype errCause struct{ cause error }\n"
+	if err := os.WriteFile(filepath.Join(dir, "err_def.go"), []byte(generated), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+
+	files := []string{filepath.Join(dir, "wrap.go"), filepath.Join(dir, "err_def.go")}
+	g := Generator{typeName: "Err"}
+	if err := g.loadPackage(files); err != nil {
+		t.Fatal(err)
+	}
+	expected := "warning: _errWrap is already declared in package test"
+	if !strings.Contains(out.String(), expected) {
+		t.Errorf("output does not contain %q:\n%s", expected, out.String())
+	}
+
+	out.Reset()
+	g = Generator{typeName: "Err", wrapType: "errCause"}
+	if err := g.loadPackage(files); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() > 0 {
+		t.Errorf("expected no warning, got:\n%s", out.String())
+	}
+}
+
+func TestLoadPackageCollectsOnce(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "blocks.go")
+	src := `package test
+type Err string
+const (
+	ErrOpen = Err("failed to open file")
+	ErrRead = Err("failed to read file")
+)
+const ErrClose = Err("failed to close file")`
+	if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	g := Generator{typeName: "Err"}
+	if err := g.loadPackage([]string{file}); err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[string]int)
+	for _, spec := range g.specs {
+		counts[spec.name]++
+	}
+	for _, name := range []string{"ErrOpen", "ErrRead", "ErrClose"} {
+		if counts[name] != 1 {
+			t.Errorf("%s: found %d times, expected once", name, counts[name])
+		}
+	}
+	if len(g.specs) != 3 {
+		t.Errorf("found %d specs, expected 3", len(g.specs))
+	}
+}
+
+func TestUntypedInGroup(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "untyped.go")
+	src := `package test
+type Err string
+const (
+	ErrOpen Err = "failed to open file"
+	ErrRead = "failed to read file"
+	ErrClose Err = "failed to close file"
+)`
+	if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+
+	g := Generator{typeName: "Err"}
+	if err := g.loadPackage([]string{file}); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, spec := range g.specs {
+		names = append(names, spec.name)
+	}
+	// ErrRead is an untyped string constant, as in Go only constants without a value take the
+	// type of the previous ones.
+	if got, expected := strings.Join(names, " "), "ErrOpen ErrClose"; got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+	expected := "warning: ErrRead is an untyped string constant, not of type Err"
+	if !strings.Contains(out.String(), expected) {
+		t.Errorf("output does not contain %q:\n%s", expected, out.String())
+	}
+}
+
+func TestCheckType(t *testing.T) {
+	src := `package test
+import "net/http"
+type Err string
+type Code int
+type Status struct{ code int }
+type Header http.Header
+type Alias = string`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "types.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := typesOf(fset, &packages.Package{PkgPath: "test", Syntax: []*ast.File{file}})
+	cases := []struct {
+		typeName string
+		found    int
+		err      string
+	}{
+		{"Err", 1, ""},
+		{"Err", 0, ""},
+		{"Alias", 1, ""},
+		{"Code", 1, ""},
+		{"Code", 0, "type Code is an integer type, but none of its constants has a //gorror: comment"},
+		{"Status", 0, "type Status is not a string type: its underlying type is struct{code int}"},
+		{"Header", 0, ""},
+		{"Error", 0, "type Error is not declared in package test"},
+	}
+	for _, c := range cases {
+		err := checkType(pkg, c.typeName, c.found)
+		switch {
+		case c.err == "" && err != nil:
+			t.Errorf("%s: unexpected error: %v", c.typeName, err)
+		case c.err != "" && (err == nil || err.Error() != c.err):
+			t.Errorf("%s: got error %v, expected %q", c.typeName, err, c.err)
+		}
+	}
+}
+
+func TestSortSpecs(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.go": `package test
+type Err string
+const (
+	ErrWrite = Err("nowrap:failed to write")
+	ErrClose = Err("nowrap:failed to close")
+)`,
+		"b.go": `package test
+const ErrAccess = Err("nowrap:failed to access")
+const ErrOpen = Err("nowrap:failed to open")`,
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	g := Generator{typeName: "Err"}
+	if err := g.loadPackage([]string{filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")}); err != nil {
+		t.Fatal(err)
+	}
+	g.sortSpecs()
+	for _, spec := range g.specs {
+		g.generate(spec)
+	}
+	out, err := g.format()
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := string(out)
+	last := -1
+	for _, name := range []string{"errAccess", "errClose", "errOpen", "errWrite"} {
+		i := strings.Index(src, "type "+name+" struct")
+		if i < 0 {
+			t.Fatalf("%s: struct definition not found", name)
+		}
+		if i < last {
+			t.Errorf("%s: struct definition out of order", name)
+		}
+		last = i
+	}
+}
+
+func TestCheckCoverage(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "coverage.go")
+	src := `package test
+type Err string
+const prefix = "failed to "
+const (
+	ErrOpen = Err("failed to open file")
+	ErrRead = Err(prefix + "read file")
+)`
+	if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	g := Generator{typeName: "Err"}
+	if err := g.loadPackage([]string{file}); err != nil {
+		t.Fatal(err)
+	}
+	for _, spec := range g.specs {
+		g.generate(spec)
+	}
+	err := g.checkCoverage()
+	if err == nil {
+		t.Fatal("expected coverage check to fail")
+	}
+	expected := "generated 1 of 2 errors of type Err, missing: ErrRead (no string value nor //gorror: comment)"
+	if err.Error() != expected {
+		t.Errorf("got %q, expected %q", err, expected)
+	}
+}
+
+func TestCheckDuplicates(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "duplicates.go")
+	src := `package test
+type Err string
+const (
+	ErrOpen     = Err("failed to open file")
+	ErrOpenSpec = Err("failed to open file again")
+	ErrReadSpec = Err("failed to read file")
+)`
+	if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	g := Generator{typeName: "Err"}
+	if err := g.loadPackage([]string{file}); err != nil {
+		t.Fatal(err)
+	}
+	g.sortSpecs()
+	if err := checkDuplicates([]*Generator{&g}); err != nil {
+		t.Errorf("unexpected error without suffix: %s", err)
+	}
+	g.specSuffix = "Spec"
+	err := checkDuplicates([]*Generator{&g})
+	if err == nil {
+		t.Fatal("expected duplicates check to fail")
+	}
+	expected := "duplicate errors: ErrOpen and ErrOpenSpec both generate errOpen"
+	if err.Error() != expected {
+		t.Errorf("got %q, expected %q", err, expected)
+	}
+}
+
+func TestCheckSpecs(t *testing.T) {
+	for _, test := range []struct {
+		g        Generator
+		template string
+		expected string
+	}{
+		{Generator{}, "failed to send {{msg string %q}}", ""},
+		{Generator{cacheMsg: true}, "failed to send {{msg string %q}}",
+			"ErrSend: field msg clashes with the one generated for -cache-msg"},
+		{Generator{reqIDKey: "reqIDKey{}"}, "failed to send {{requestID string %q}}",
+			"ErrSend: field requestID clashes with the one generated for -reqid"},
+		{Generator{context: true}, "failed to send {{ctx string %q}}",
+			"ErrSend: field ctx clashes with the one generated for -context"},
+		{Generator{options: true}, "failed to send {{o opt string %q}}",
+			"ErrSend: field o clashes with the one generated for -options"},
+		{Generator{}, "multiwrap:failed to send {{causes int %d}}",
+			"ErrSend: field causes clashes with the one generated for the causes"},
+		// Local identifiers of the constructors do not collide.
+		{Generator{cacheMsg: true, reqIDKey: "reqIDKey{}"}, "failed to send {{e int %d}} {{ctx string %s}}", ""},
+	} {
+		g := test.g
+		g.typeName = "Err"
+		g.specs = []ErrorSpec{{"ErrSend", test.template, "", mustParse(t, test.template)}}
+		err := g.checkSpecs()
+		switch {
+		case test.expected == "" && err != nil:
+			t.Errorf("%q: unexpected error: %v", test.template, err)
+		case test.expected != "" && (err == nil || err.Error() != test.expected):
+			t.Errorf("%q: got error %v, expected %q", test.template, err, test.expected)
+		}
+	}
+}
+
+func TestCheckReplacements(t *testing.T) {
+	for _, test := range []struct{ src, expected string }{
+		{`ErrOpen = Err("deprecated:use ErrOpenFile failed to open")
+	ErrOpenFile = Err("failed to open file")`, ""},
+		{`ErrOpen = Err("deprecated:use ErrOpenFile failed to open")`,
+			"ErrOpen: replacement ErrOpenFile is not an error of type Err"},
+		{`ErrOpen = Err("deprecated:use ErrOpen failed to open")`, "ErrOpen: deprecated in favor of itself"},
+	} {
+		file := filepath.Join(t.TempDir(), "replacements.go")
+		src := "package test\ntype Err string\nconst (\n\t" + test.src + "\n)"
+		if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		g := Generator{typeName: "Err"}
+		if err := g.loadPackage([]string{file}); err != nil {
+			t.Fatal(err)
+		}
+		err := checkReplacements([]*Generator{&g})
+		switch {
+		case test.expected == "" && err != nil:
+			t.Errorf("unexpected error: %s", err)
+		case test.expected != "" && (err == nil || err.Error() != test.expected):
+			t.Errorf("got %v, expected %q", err, test.expected)
+		}
+	}
+}
+
+func TestBuildContext(t *testing.T) {
+	// Files given on the command line are loaded regardless of build constraints, use the
+	// package directory.
+	pattern := []string{"./testdata/buildtags"}
+	for _, test := range []struct {
+		gen      Generator
+		expected string
+	}{
+		{Generator{goos: "linux"}, "ErrOpen"},
+		{Generator{goos: "linux", buildTags: "special"}, "ErrOpen ErrSpecial"},
+		{Generator{goos: "plan9", goarch: "amd64"}, "ErrOpen ErrPlan9"},
+	} {
+		g := test.gen
+		g.typeName = "Err"
+		if err := g.loadPackage(pattern); err != nil {
+			t.Fatal(err)
+		}
+		g.sortSpecs()
+		var names []string
+		for _, spec := range g.specs {
+			names = append(names, spec.name)
+		}
+		if got := strings.Join(names, " "); got != test.expected {
+			t.Errorf("tags=%q goos=%q: got %q, expected %q", g.buildTags, g.goos, got, test.expected)
+		}
+	}
+}
+
+func TestPackageOverride(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "pkg.go")
+	src := `package test
+type Err string
+const ErrOpen = Err("failed to open file")`
+	if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct{ outPkg, expected string }{
+		{"", "package test\n"},
+		{"other", "package other\n"},
+	} {
+		g := Generator{typeName: "Err", outPkg: test.outPkg}
+		if err := g.loadPackage([]string{file}); err != nil {
+			t.Fatal(err)
+		}
+		if err := g.header(); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(g.buf.String(), test.expected) {
+			t.Errorf("outPkg %q: header does not contain %q:\n%s", test.outPkg, test.expected, g.buf.String())
+		}
+	}
+}
+
+func TestBuildConstraint(t *testing.T) {
+	g := Generator{pkgName: "test", buildCons: "linux && amd64"}
+	if err := g.fileHeader(nil); err != nil {
+		t.Fatal(err)
+	}
+	expected := "//go:build linux && amd64\n\n" + generatedHeader + "\n\npackage test\n\n"
+	if got := g.buf.String(); got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+
+	// The output is still recognized as generated.
+	file, err := parser.ParseFile(token.NewFileSet(), "err_def.go", g.buf.String(), parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isGenerated(file) {
+		t.Error("file with build constraint not recognized as generated")
+	}
+}
+
+func TestMinGo(t *testing.T) {
+	specs := []ErrorSpec{{"ErrClose", "multiwrap:failed to close", "", mustParse(t, "multiwrap:failed to close")}}
+	g := Generator{typeName: "Err", pkgName: "test", minGo: "go1.20", specs: specs}
+	if err := g.checkGoVersion(); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.fileHeader(nil); err != nil {
+		t.Fatal(err)
+	}
+	expected := "//go:build go1.20\n\n" + generatedHeader + "\n\npackage test\n\n"
+	if got := g.buf.String(); got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+
+	g = Generator{pkgName: "test", minGo: "go1.18", buildCons: "linux || darwin"}
+	if got, expected := g.constraint(), "(linux || darwin) && go1.18"; got != expected {
+		t.Errorf("got constraint %q, expected %q", got, expected)
+	}
+
+	g = Generator{typeName: "Err", minGo: "go1.19", specs: specs}
+	expectedErr := "multiwrap: errors, used by the errors of type Err, needs go1.20, newer than -min-go go1.19"
+	if err := g.checkGoVersion(); err == nil || err.Error() != expectedErr {
+		t.Errorf("got error %v, expected %q", err, expectedErr)
+	}
+	g = Generator{typeName: "Err", minGo: "go1.18", logOnce: true}
+	if err := g.checkGoVersion(); err == nil || !strings.Contains(err.Error(), "-log-once") {
+		t.Errorf("expected an error about -log-once, got %v", err)
+	}
+}
+
+func TestNolint(t *testing.T) {
+	g := Generator{pkgName: "test", buildCons: "linux", nolint: "gocyclo,stylecheck"}
+	if err := g.fileHeader([]string{"fmt"}); err != nil {
+		t.Fatal(err)
+	}
+	expected := "//go:build linux\n\n" + generatedHeader + "\n\n//nolint:gocyclo,stylecheck\npackage test\n\n" +
+		"import (\n\t\"fmt\"\n)\n\n"
+	if got := g.buf.String(); got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), "err_def.go", g.buf.String(), parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isGenerated(file) {
+		t.Error("file with nolint directive not recognized as generated")
+	}
+	// The directive is attached to the package clause, the build constraint stays first.
+	if file.Doc == nil || file.Doc.Text() != "" || file.Doc.List[0].Text != "//nolint:gocyclo,stylecheck" {
+		t.Errorf("nolint directive not attached to the package clause: %v", file.Doc)
+	}
+	if !constraint.IsGoBuild(file.Comments[0].List[0].Text) {
+		t.Errorf("build constraint not first: %q", file.Comments[0].List[0].Text)
+	}
+}
+
+func TestIsGenerated(t *testing.T) {
+	// Go tools recognize generated files by this pattern.
+	if !regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`).MatchString(generatedHeader) {
+		t.Errorf("header %q is not in the canonical form", generatedHeader)
+	}
+	for _, test := range []struct {
+		header    string
+		generated bool
+	}{
+		{generatedHeader, true},
+		{legacyHeader, true},
+		{"// Package test has errors.", false},
+	} {
+		src := test.header + "\n\npackage test\n"
+		file, err := parser.ParseFile(token.NewFileSet(), "err_def.go", src, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := isGenerated(file); got != test.generated {
+			t.Errorf("%q: got %t, expected %t", test.header, got, test.generated)
+		}
+	}
+}
+
+func TestOutputName(t *testing.T) {
+	for _, test := range []struct{ output, suffix, expected string }{
+		{"", "_def.go", filepath.Join("src", "myerr_def.go")},
+		{"", "_errors.gen.go", filepath.Join("src", "myerr_errors.gen.go")},
+		{"out/errors.go", "_errors.gen.go", "out/errors.go"},
+	} {
+		if got := outputName(test.output, "src", "MyErr", test.suffix); got != test.expected {
+			t.Errorf("output %q, suffix %q: got %q, expected %q", test.output, test.suffix, got, test.expected)
+		}
+	}
+}
+
+func TestPackageName(t *testing.T) {
+	for _, test := range []struct {
+		g        Generator
+		expected string
+	}{
+		{Generator{outPkg: "override", pkgName: "source", outDir: "/tmp/dir"}, "override"},
+		{Generator{pkgName: "source", outDir: "/tmp/dir"}, "source"},
+		{Generator{outDir: "/tmp/dir"}, "dir"},
+	} {
+		got, err := test.g.packageName()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.expected {
+			t.Errorf("got %q, expected %q", got, test.expected)
+		}
+	}
+
+	// A directory that is not an identifier needs -pkg.
+	g := Generator{outDir: "/tmp/my-dir"}
+	if _, err := g.packageName(); err == nil {
+		t.Error("expected an error for directory my-dir")
+	}
+}
+
+func TestInterfaceImport(t *testing.T) {
+	for _, test := range []struct {
+		iface, imp, name string
+		imports          []string
+	}{
+		{"DomainError", "", "DomainError", []string{"fmt", "errors"}},
+		{"example.com/app/errs.DomainError", "", "errs.DomainError", []string{"fmt", "errors", "example.com/app/errs"}},
+		{"errs.DomainError", "errs=example.com/app/errs", "errs.DomainError",
+			[]string{"fmt", "errs=example.com/app/errs", "errors"}},
+		{"example.com/app/errs/v2.DomainError", "", "errs.DomainError",
+			[]string{"fmt", "errors", "example.com/app/errs/v2"}},
+		{"example.com/go-errs.DomainError", "", "errs.DomainError",
+			[]string{"fmt", "errors", "example.com/go-errs"}},
+		{"gopkg.in/errs.v1.DomainError", "", "errs.DomainError",
+			[]string{"fmt", "errors", "gopkg.in/errs.v1"}},
+	} {
+		g := Generator{iface: test.iface}
+		if test.imp != "" {
+			g.imports = []string{test.imp}
+		}
+		if got := g.ifaceName(); got != test.name {
+			t.Errorf("%s: got name %q, expected %q", test.iface, got, test.name)
+		}
+		if got := g.importList(); !reflect.DeepEqual(got, test.imports) {
+			t.Errorf("%s: got imports %q, expected %q", test.iface, got, test.imports)
+		}
+	}
+}
+
+func TestInterfacePackage(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"go.mod":            "module example.com/app\n\ngo 1.16\n",
+		"api-codes/v2/c.go": "package codes\n\ntype DomainError interface{ error }\n",
+	})
+	g := Generator{iface: "example.com/app/api-codes/v2.DomainError"}
+	if g.ifacePkg = g.ifacePackage(dir); g.ifacePkg != "codes" {
+		t.Errorf("got package %q, expected %q", g.ifacePkg, "codes")
+	}
+	if got := g.ifaceName(); got != "codes.DomainError" {
+		t.Errorf("got name %q, expected %q", got, "codes.DomainError")
+	}
+}
+
+func TestVerbRE(t *testing.T) {
+	for verb, expected := range map[string]bool{
+		"%v": true, "%+v": true, "%s": true, "%-10.3s": true,
+		"": false, "v": false, "%": false, "%v%v": false, ": %v": false,
+	} {
+		if got := verbRE.MatchString(verb); got != expected {
+			t.Errorf("%q: got %t, expected %t", verb, got, expected)
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "summary.go")
+	src := `package test
+type Err string
+const (
+	ErrOpen  = Err("failed to open {{file string %q}}")
+	ErrRead  = Err("nowrap:failed to read")
+	ErrParse = Err("wrap:failed to parse line {{line int %d}}")
+	ErrSync  = Err("multiwrap:failed to sync")
+	ErrClose = Err("failed to close")
+)`
+	if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	g := Generator{typeName: "Err"}
+	if err := g.loadPackage([]string{file}); err != nil {
+		t.Fatal(err)
+	}
+	expected := "summary: generated 5 errors of type Err, 2 with fields " +
+		"(optwrap 2, wrap 1, nowrap 1, multiwrap 1) to err_def.go"
+	if got := summary([]*Generator{&g}, []string{"err_def.go"}); got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+}
+
+// mustParse parses a template, failing the test on error.
+func mustParse(t *testing.T, template string) ParsedTemplate {
+	t.Helper()
+	parsed, err := parseTemplate(template)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
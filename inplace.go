@@ -0,0 +1,133 @@
+// (c) Copyright 2021, Gorror Authors.
+//
+// Licensed under the terms of the GNU GPL License version 3.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"strconv"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// Markers delimiting the generated code in a file written with -inplace.
+const (
+	beginMarker = "// gorror:begin"
+	endMarker   = "// gorror:end"
+)
+
+// ownImports are the imports that the generated code may use, which are removed from a file
+// written with -inplace when no longer used.
+var ownImports = map[string]bool{
+	"context":     true,
+	"errors":      true,
+	"fmt":         true,
+	"reflect":     true,
+	"regexp":      true,
+	"runtime":     true,
+	"strconv":     true,
+	"strings":     true,
+	"sync/atomic": true,
+}
+
+// inplaceSource returns the content of the target file with the code between the markers
+// replaced by the declarations of the generated file src. Without markers, they are appended
+// together with the declarations. When the target does not exist, it is the generated file
+// with the declarations between markers.
+func inplaceSource(target string, src []byte) ([]byte, error) {
+	existing, err := os.ReadFile(target)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return splice(existing, src)
+}
+
+// splice replaces the region between the markers of the existing file with the declarations
+// of the generated file src, merging their imports. A nil existing file stands for the header
+// of the generated one.
+func splice(existing, src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	gen, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parsing generated code: %w", err)
+	}
+	// The declarations follow the package clause and the imports.
+	offset := gen.Name.End()
+	for _, decl := range gen.Decls {
+		if d, ok := decl.(*ast.GenDecl); ok && d.Tok == token.IMPORT {
+			offset = d.End()
+		}
+	}
+	body := bytes.TrimSpace(src[fset.Position(offset).Offset:])
+	if existing == nil {
+		existing = src[:fset.Position(offset).Offset]
+	}
+
+	var buf bytes.Buffer
+	begin, end := bytes.Index(existing, []byte(beginMarker)), bytes.Index(existing, []byte(endMarker))
+	switch {
+	case begin < 0 && end < 0:
+		buf.Write(bytes.TrimRight(existing, "\n"))
+		fmt.Fprintf(&buf, "\n\n%s\n%s\n%s\n", beginMarker, body, endMarker)
+	case begin < 0 || end < begin:
+		return nil, fmt.Errorf("%s must come before %s", beginMarker, endMarker)
+	case bytes.Count(existing, []byte(beginMarker)) > 1 || bytes.Count(existing, []byte(endMarker)) > 1:
+		return nil, fmt.Errorf("found several %s or %s markers", beginMarker, endMarker)
+	default:
+		buf.Write(existing[:begin])
+		fmt.Fprintf(&buf, "%s\n%s\n", beginMarker, body)
+		buf.Write(existing[end:])
+	}
+
+	// Merge the imports of the generated code, dropping the ones it no longer uses.
+	fset = token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", buf.Bytes(), parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parsing file with generated code: %w", err)
+	}
+	for _, imp := range gen.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		if imp.Name != nil {
+			astutil.AddNamedImport(fset, file, imp.Name.Name, path)
+		} else {
+			astutil.AddImport(fset, file, path)
+		}
+	}
+	for _, imp := range append([]*ast.ImportSpec(nil), file.Imports...) {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		if ownImports[path] && imp.Name == nil && !astutil.UsesImport(file, path) {
+			astutil.DeleteImport(fset, file, path)
+		}
+	}
+	for _, decl := range file.Decls {
+		if d, ok := decl.(*ast.GenDecl); ok && d.Tok == token.IMPORT && len(d.Specs) == 1 {
+			// Left over by deleting imports, the parentheses are not needed anymore.
+			d.Lparen, d.Rparen = token.NoPos, token.NoPos
+		}
+	}
+	var out bytes.Buffer
+	if err := format.Node(&out, fset, file); err != nil {
+		return nil, err
+	}
+	return format.Source(out.Bytes())
+}
+
+// hasRegion reports whether the file holds code generated with -inplace.
+func hasRegion(file *ast.File) bool {
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			if c.Text == beginMarker {
+				return true
+			}
+		}
+	}
+	return false
+}
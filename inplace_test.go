@@ -0,0 +1,119 @@
+// (c) Copyright 2021, Gorror Authors.
+//
+// Licensed under the terms of the GNU GPL License version 3.
+
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInplace(t *testing.T) {
+	defer resetFlags()
+	tmpdir := t.TempDir()
+	src := `package errs
+
+import "os"
+
+type Err string
+
+const ErrOpen = Err("failed to open {{name string %q}}")
+
+// Open opens a file, failing with ErrOpen.
+func Open(name string) (*os.File, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, newErrOpen(name).Wrap(err)
+	}
+	return f, nil
+}
+
+// gorror:begin
+// gorror:end
+
+// Exists reports whether a file exists.
+func Exists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
+`
+	for name, content := range map[string]string{
+		"go.mod":       "module example.com/app\n\ngo 1.16\n",
+		"errs/errs.go": src,
+	} {
+		path := filepath.Join(tmpdir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(tmpdir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	// The second run replaces the code generated by the first one.
+	for i := 0; i < 2; i++ {
+		resetFlags()
+		err := flag.CommandLine.Parse([]string{"-type", "Err", "-inplace", "-output", "errs/errs.go", "./errs"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := execute(flag.Args()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out, err := os.ReadFile(filepath.Join("errs", "errs.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"func Open(name string)", "func Exists(name string)", "type errOpen struct"} {
+		if strings.Count(string(out), s) != 1 {
+			t.Errorf("expected %q once in:\n%s", s, out)
+		}
+	}
+	if strings.Contains(string(out), generatedHeader) {
+		t.Errorf("unexpected generated header in:\n%s", out)
+	}
+	begin, end := strings.Index(string(out), beginMarker), strings.Index(string(out), endMarker)
+	if begin < strings.Index(string(out), "func Open") || end > strings.Index(string(out), "func Exists") {
+		t.Errorf("generated code not between the hand-written functions:\n%s", out)
+	}
+	if err := run("go", "build", "./errs"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSplice(t *testing.T) {
+	generated := []byte(generatedHeader + "\n\npackage test\n\nimport \"fmt\"\n\nvar x = fmt.Sprint()\n")
+	for _, test := range []struct {
+		existing, expected, err string
+	}{
+		{"package test\n\nfunc f() {}\n",
+			"package test\n\nimport \"fmt\"\n\nfunc f() {}\n\n// gorror:begin\nvar x = fmt.Sprint()\n\n// gorror:end\n", ""},
+		{"package test\n\nimport \"errors\"\n\n// gorror:begin\nvar y = errors.New(\"\")\n// gorror:end\n",
+			"package test\n\nimport \"fmt\"\n\n// gorror:begin\nvar x = fmt.Sprint()\n\n// gorror:end\n", ""},
+		{"package test\n\n// gorror:end\n// gorror:begin\n", "", "// gorror:begin must come before // gorror:end"},
+		{"package test\n\n// gorror:begin\n", "", "// gorror:begin must come before // gorror:end"},
+	} {
+		got, err := splice([]byte(test.existing), generated)
+		switch {
+		case test.err != "" && (err == nil || err.Error() != test.err):
+			t.Errorf("%q: got error %v, expected %q", test.existing, err, test.err)
+		case test.err == "" && err != nil:
+			t.Errorf("%q: unexpected error: %v", test.existing, err)
+		case test.err == "" && string(got) != test.expected:
+			t.Errorf("%q: got\n%s\nexpected\n%s", test.existing, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Err string
+
+const (
+	ErrOpen = Err("failed to open {{file string %q}}")
+	ErrRead = Err("nowrap:failed to read")
+)
+
+func main() {
+	err := fmt.Errorf("loading: %w", newErrOpen("data.txt").Wrap(newErrRead()))
+	e, ok := asErrOpen(err)
+	if !ok || e.file != "data.txt" {
+		panic(fmt.Sprintf("ErrOpen not extracted from chain: %v, %v", e, ok))
+	}
+	if _, ok := asErrRead(err); !ok {
+		panic("ErrRead cause not extracted from chain")
+	}
+	if e, ok := asErrOpen(errors.New("failed to open")); ok || e != nil {
+		panic("unrelated error extracted")
+	}
+}
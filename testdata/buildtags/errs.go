@@ -0,0 +1,5 @@
+package buildtags
+
+type Err string
+
+const ErrOpen = Err("failed to open {{file string %q}}")
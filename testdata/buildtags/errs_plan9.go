@@ -0,0 +1,3 @@
+package buildtags
+
+const ErrPlan9 = Err("plan9 failure")
@@ -0,0 +1,6 @@
+//go:build special
+// +build special
+
+package buildtags
+
+const ErrSpecial = Err("special failure")
@@ -0,0 +1,29 @@
+package main
+
+import "errors"
+
+type Err string
+
+const (
+	ErrOpen  = Err("failed to open {{file string %q}}")
+	ErrRead  = Err("wrap:failed to read {{file string %q}}")
+	ErrLimit = Err("nowrap:limit {{e int %d}} exceeded")
+)
+
+func main() {
+	e := newErrOpen("data.txt")
+	if e.Error() != `failed to open "data.txt"` {
+		panic("unexpected message " + e.Error())
+	}
+	e.Wrap(errors.New("not found"))
+	if e.Error() != `failed to open "data.txt": not found` {
+		panic("cached message not updated by Wrap: " + e.Error())
+	}
+	r := newErrRead("data.txt", errors.New("EOF"))
+	if r.Error() != `failed to read "data.txt": EOF` {
+		panic("unexpected message " + r.Error())
+	}
+	if l := newErrLimit(3); l.Error() != "limit 3 exceeded" {
+		panic("unexpected message " + l.Error())
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Err string
+
+const ErrExec = Err("command {{cmd string %s}} failed: {{reason error %v}} (exit {{code int %d}})")
+
+func main() {
+	base := errors.New("killed")
+	err := newErrExec("make", base, 2)
+	if got, expected := err.Error(), "command make failed: killed (exit 2)"; got != expected {
+		panic(fmt.Sprintf("got %q, expected %q", got, expected))
+	}
+	if !errors.Is(err, base) || !ErrExec.IsIn(fmt.Errorf("running: %w", err)) {
+		panic("cause not in chain")
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Err string
+
+const (
+	ErrOpen = Err("failed to open {{file string %q}}")
+	ErrLoad = Err("wrap:failed to load {{name string %s}}")
+)
+
+// cause mirrors errors.Cause of github.com/pkg/errors, following the Cause methods.
+func cause(err error) error {
+	type causer interface{ Cause() error }
+	for err != nil {
+		c, ok := err.(causer)
+		if !ok {
+			break
+		}
+		err = c.Cause()
+	}
+	return err
+}
+
+func main() {
+	inner := errors.New("permission denied")
+	err := newErrLoad("config", newErrOpen("config.json").Wrap(inner))
+	if got := cause(err); got != inner {
+		panic(fmt.Sprintf("got %v, expected %v", got, inner))
+	}
+	if !errors.Is(err, inner) {
+		panic("cause not in the standard library chain")
+	}
+}
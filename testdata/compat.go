@@ -0,0 +1,17 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Err string
+
+const ErrRead = Err("nowrap:failed to read")
+
+func main() {
+	e := fmt.Errorf("loading: %w", newErrRead())
+	if !errors.Is(e, ErrRead) {
+		panic("ErrRead not in error")
+	}
+}
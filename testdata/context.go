@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Err string
+
+const (
+	ErrOpen  = Err("failed to open {{file string %q}}")
+	ErrClose = Err("nowrap:failed to close")
+	ErrThin  = Err("wrap:")
+)
+
+func check(got, expected string) {
+	if got != expected {
+		panic(fmt.Sprintf("got %q, expected %q", got, expected))
+	}
+}
+
+func main() {
+	check(newErrOpen("a.txt").Error(), `failed to open "a.txt"`)
+	err := newErrOpen("a.txt").With("user", "bob").With("attempt", 3).With("mode", 0644)
+	check(err.Error(), `failed to open "a.txt" [attempt=3 mode=420 user=bob]`)
+	err.Wrap(errors.New("denied"))
+	check(err.Error(), `failed to open "a.txt": denied [attempt=3 mode=420 user=bob]`)
+	check(newErrClose().With("fd", 3).Error(), "failed to close [fd=3]")
+
+	// The context follows the message whatever the verb.
+	check(fmt.Sprintf("%v", err), `failed to open "a.txt": denied [attempt=3 mode=420 user=bob]`)
+	check(fmt.Sprintf("%s", err), `failed to open "a.txt" [attempt=3 mode=420 user=bob]`)
+	check(fmt.Sprintf("%+v", err), `failed to open "a.txt": denied [attempt=3 mode=420 user=bob]`)
+	check(err.CLIString(), `failed to open "a.txt": denied [attempt=3 mode=420 user=bob]`)
+	check(newErrOpen("a.txt").With("user", "bob").GoString(),
+		`errOpen{file: "a.txt", cause: <nil>, ctx: map[string]interface {}{"user":"bob"}}`)
+	thin := newErrThin(errors.New("denied")).With("user", "bob")
+	check(fmt.Sprintf("%s", thin), " [user=bob]")
+	check(fmt.Sprintf("%+v", thin), "denied [user=bob]")
+	check(thin.CLIString(), "denied [user=bob]")
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Err string
+
+const (
+	ErrOpen    = Err("wrap:failed to open {{file string %q}} (mode {{mode int %d}})")
+	ErrTimeout = Err("multiwrap:timed out after {{secs int %d}}s")
+)
+
+func main() {
+	cause := errors.New("permission denied")
+	orig := newErrOpen("data.txt", 4, cause)
+	e, err := errCtors[ErrOpen]("data.txt", 4, cause)
+	if err != nil {
+		panic(err)
+	}
+	if e.Error() != orig.Error() || !ErrOpen.IsIn(e) || !errors.Is(e, cause) {
+		panic(fmt.Sprintf("reconstructed %q, expected %q", e, orig))
+	}
+
+	e, err = errCtors[ErrTimeout](3, cause, errors.New("canceled"))
+	if err != nil {
+		panic(err)
+	}
+	if e.Error() != "timed out after 3s: permission denied; canceled" {
+		panic(fmt.Sprintf("unexpected message %q", e))
+	}
+
+	if _, err := errCtors[ErrOpen]("data.txt"); err == nil {
+		panic("missing arguments accepted")
+	}
+	if _, err := errCtors[ErrOpen]("data.txt", "4", cause); err == nil {
+		panic("argument of the wrong type accepted")
+	}
+	if _, err := errCtors[ErrTimeout](3, "canceled"); err == nil {
+		panic("cause of the wrong type accepted")
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Err string
+
+const (
+	ErrThin  = Err("wrap:")
+	ErrNone  = Err("nowrap:")
+	ErrMaybe = Err("")
+)
+
+func check(got, expected string) {
+	if got != expected {
+		panic(fmt.Sprintf("got %q, expected %q", got, expected))
+	}
+}
+
+func main() {
+	cause := errors.New("disk full")
+	check(newErrThin(cause).Error(), "disk full")
+	check(newErrNone().Error(), "")
+	check(newErrMaybe().Error(), "")
+	check(newErrMaybe().Wrap(cause).Error(), "disk full")
+	if !ErrThin.IsIn(newErrThin(cause)) || !errors.Is(newErrThin(cause), cause) {
+		panic("thin wrapper not matched")
+	}
+}
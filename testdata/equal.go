@@ -0,0 +1,23 @@
+package main
+
+import "errors"
+
+type Err string
+
+const (
+	ErrOpen  = Err("failed to open {{file string %q}}")
+	ErrParse = Err("nowrap:failed to parse {{file string %q}} at {{lines ...int %v}}")
+)
+
+func main() {
+	base := errors.New("denied")
+	if !newErrOpen("a.txt").Wrap(base).(*errOpen).Equal(newErrOpen("a.txt").Wrap(base).(*errOpen)) {
+		panic("equal errors differ")
+	}
+	if newErrOpen("a.txt").Equal(newErrOpen("b.txt")) || newErrOpen("a.txt").Wrap(base).(*errOpen).Equal(newErrOpen("a.txt")) {
+		panic("different errors are equal")
+	}
+	if !newErrParse("a.go", 1, 2).Equal(newErrParse("a.go", 1, 2)) || newErrParse("a.go", 1).Equal(newErrParse("a.go", 2)) {
+		panic("variadic fields not compared by value")
+	}
+}
@@ -0,0 +1,10 @@
+package fast
+
+// Err and FastErr declare the same error, generated with fmt.Sprintf and with -fast.
+type Err string
+
+type FastErr string
+
+const ErrFileOp = Err("failed to {{op string %s}} {{file string %q}} (code {{code int %d}})")
+
+const FastErrFileOp = FastErr("failed to {{op string %s}} {{file string %q}} (code {{code int %d}})")
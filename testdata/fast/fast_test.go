@@ -0,0 +1,36 @@
+package fast
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSameMessage(t *testing.T) {
+	for _, file := range []string{"data.txt", "tab\there", "\"quoted\" ünïcode"} {
+		slow := newErrFileOp("open", file, -42)
+		fast := newFastErrFileOp("open", file, -42)
+		if slow.Error() != fast.Error() {
+			t.Errorf("got %q, expected %q", fast.Error(), slow.Error())
+		}
+		cause := errors.New("permission denied")
+		if slow.Wrap(cause).Error() != fast.Wrap(cause).Error() {
+			t.Errorf("got %q, expected %q", fast.Error(), slow.Error())
+		}
+	}
+}
+
+func BenchmarkSprintf(b *testing.B) {
+	err := newErrFileOp("open", "data.txt", 42)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = err.Error()
+	}
+}
+
+func BenchmarkFast(b *testing.B) {
+	err := newFastErrFileOp("open", "data.txt", 42)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = err.Error()
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Err string
+
+const (
+	ErrOpen = Err("failed to open {{file string %q}}")
+	ErrRead = Err("nowrap:failed to read")
+)
+
+func main() {
+	e := newErrOpen("filename.txt").Wrap(errors.New("some cause"))
+	for _, test := range []struct{ format, expected string }{
+		{"%s", `failed to open "filename.txt"`},
+		{"%v", `failed to open "filename.txt": some cause`},
+		{"%+v", `failed to open "filename.txt": some cause`},
+		{"%q", `"failed to open \"filename.txt\": some cause"`},
+	} {
+		if got := fmt.Sprintf(test.format, e); got != test.expected {
+			panic(fmt.Sprintf("%s: got %q, expected %q", test.format, got, test.expected))
+		}
+	}
+	if got := fmt.Sprintf("%s", newErrRead()); got != "failed to read" {
+		panic("%s: got " + got)
+	}
+}
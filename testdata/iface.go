@@ -0,0 +1,29 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Err string
+
+const (
+	ErrOpen = Err("failed to open {{file string %q}}")
+	ErrRead = Err("nowrap:failed to read")
+)
+
+type DomainError interface {
+	error
+	Domain() string
+}
+
+func main() {
+	var err error = newErrOpen("config.json").Wrap(newErrRead())
+	var de DomainError
+	if !errors.As(err, &de) {
+		panic("no DomainError in chain")
+	}
+	if got, expected := de.Domain(), "storage"; got != expected {
+		panic(fmt.Sprintf("got %q, expected %q", got, expected))
+	}
+}
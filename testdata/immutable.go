@@ -0,0 +1,27 @@
+package main
+
+import "errors"
+
+type Err string
+
+const ErrOpen = Err("failed to open {{file string %q}}")
+
+func main() {
+	first := errors.New("first cause")
+	second := errors.New("second cause")
+	e := newErrOpen("filename.txt")
+	e1 := e.Wrap(first)
+	e2 := e.Wrap(second)
+	if e.Error() != `failed to open "filename.txt"` {
+		panic("original error modified: " + e.Error())
+	}
+	if !errors.Is(e1, first) || errors.Is(e1, second) {
+		panic("wrong cause for e1: " + e1.Error())
+	}
+	if !errors.Is(e2, second) || errors.Is(e2, first) {
+		panic("wrong cause for e2: " + e2.Error())
+	}
+	if !ErrOpen.IsIn(e1) || !ErrOpen.IsIn(e2) {
+		panic("ErrOpen.IsIn")
+	}
+}
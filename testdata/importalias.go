@@ -0,0 +1,14 @@
+package main
+
+import tm "time"
+
+type Err string
+
+const ErrTimeout = Err("timed out after {{d tm.Duration %s}}")
+
+func main() {
+	e := newErrTimeout(2 * tm.Second)
+	if e.Error() != "timed out after 2s" {
+		panic("wrong error message: " + e.Error())
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+type Err string
+
+const (
+	ErrStat = Err("stat {{path string %q}} failed: {{inner error %w}}")
+	ErrLoad = Err("failed to load config: {{err error %w}}")
+)
+
+func check(got, expected string) {
+	if got != expected {
+		panic(fmt.Sprintf("got %q, expected %q", got, expected))
+	}
+}
+
+func main() {
+	stat := newErrStat("config.json", fs.ErrNotExist)
+	check(stat.Error(), `stat "config.json" failed: file does not exist`)
+	if !errors.Is(stat, fs.ErrNotExist) {
+		panic("inner error not found")
+	}
+
+	load := newErrLoad(fmt.Errorf("reading: %w", stat))
+	check(load.Error(), `failed to load config: reading: stat "config.json" failed: file does not exist`)
+	if !errors.Is(load, fs.ErrNotExist) || !ErrStat.IsIn(load) {
+		panic("inner error not found through the chain")
+	}
+	var target *errStat
+	if !errors.As(load, &target) || target != stat {
+		panic("errors.As did not find the inner error")
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import "errors"
+
+type Code int
+
+const (
+	CodeOK Code = iota
+	//gorror:"failed to open {{file string %q}}"
+	ErrOpen
+	ErrRead //gorror:"nowrap:failed to read"
+)
+
+func main() {
+	e := newErrOpen("filename.txt").Wrap(newErrRead())
+	if e.Error() != `failed to open "filename.txt": failed to read` {
+		panic("wrong error message: " + e.Error())
+	}
+	if !ErrOpen.IsIn(e) || CodeOK.IsIn(e) {
+		panic("wrong IsIn result")
+	}
+	var ee *errRead
+	if !errors.As(e, &ee) {
+		panic("errors.As(e, errRead)")
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Err string
+
+const (
+	ErrOpen = Err("failed to open {{file string %q}}")
+	ErrRead = Err("nowrap:failed to read")
+)
+
+func main() {
+	err := fmt.Errorf("loading: %w", newErrOpen("data.txt").Wrap(newErrRead()))
+	if !isErrOpen(err) {
+		panic("ErrOpen not found in chain")
+	}
+	if !isErrRead(err) {
+		panic("ErrRead cause not found in chain")
+	}
+	if isErrOpen(errors.New("failed to open")) || isErrRead(nil) {
+		panic("unrelated error matched")
+	}
+}
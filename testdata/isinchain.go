@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+)
+
+type Err string
+
+const (
+	ErrOpen  = Err("failed to open {{file string %q}}")
+	ErrLoad  = Err("wrap:failed to load config")
+	ErrLimit = Err("nowrap:too many open files")
+	ErrBoth  = Err("multiwrap:several failures")
+	ErrOther = Err("other failure")
+)
+
+func main() {
+	// A wrapper of another package sits between generated errors.
+	open := newErrOpen("config.json").Wrap(newErrLimit())
+	err := newErrLoad(fmt.Errorf("ctx: %w", open))
+	for _, e := range []Err{ErrLoad, ErrOpen, ErrLimit} {
+		if !e.IsIn(err) {
+			panic(fmt.Sprintf("%s not in %v", e, err))
+		}
+	}
+	if ErrOther.IsIn(err) {
+		panic(fmt.Sprintf("%s in %v", ErrOther, err))
+	}
+
+	both := newErrBoth(fmt.Errorf("first: %w", newErrOther()), fmt.Errorf("second: %w", err))
+	for _, e := range []Err{ErrBoth, ErrOther, ErrLoad, ErrLimit} {
+		if !e.IsIn(both) {
+			panic(fmt.Sprintf("%s not in %v", e, both))
+		}
+	}
+	if ErrOpen.IsIn(fmt.Errorf("plain")) || ErrOpen.IsIn(nil) {
+		panic("unexpected match")
+	}
+}
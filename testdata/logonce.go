@@ -0,0 +1,25 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Err string
+
+const ErrOpen = Err("failed to open {{file string %q}}")
+
+func main() {
+	e := newErrOpen("data.txt")
+	if !e.MarkLogged() {
+		panic("first mark reported as already logged")
+	}
+	if e.MarkLogged() {
+		panic("second mark reported as not logged")
+	}
+	w := fmt.Errorf("loading: %w", newErrOpen("other.txt"))
+	var ei interface{ MarkLogged() bool }
+	if !errors.As(w, &ei) || !ei.MarkLogged() {
+		panic("wrapped error reported as already logged")
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Err string
+
+const (
+	ErrOpen = Err("failed to open {{file string %q}}")
+	ErrRead = Err("nowrap:failed to read")
+)
+
+func main() {
+	err := fmt.Errorf("loading: %w", newErrOpen("data.txt").Wrap(newErrRead()))
+	if !errors.Is(err, ErrOpen) || !errors.Is(err, ErrRead) {
+		panic("sentinels not matched")
+	}
+	if !errors.Is(err, newErrOpen("data.txt")) {
+		panic("ErrOpen with equal fields not matched")
+	}
+	if errors.Is(err, newErrOpen("other.txt")) {
+		panic("ErrOpen with different fields matched")
+	}
+	if !errors.Is(err, newErrRead()) {
+		panic("ErrRead without fields not matched")
+	}
+}
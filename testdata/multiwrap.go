@@ -0,0 +1,22 @@
+package main
+
+import "errors"
+
+type Err string
+
+const ErrClose = Err("multiwrap:failed to close {{file string %q}}")
+
+func main() {
+	errFlush := errors.New("flush failed")
+	errSync := errors.New("sync failed")
+	e := newErrClose("filename.txt", errFlush, errSync)
+	if !errors.Is(e, errFlush) || !errors.Is(e, errSync) {
+		panic("wrapped causes not found")
+	}
+	if e.Error() != `failed to close "filename.txt": flush failed; sync failed` {
+		panic("wrong error message: " + e.Error())
+	}
+	if newErrClose("filename.txt").Error() != `failed to close "filename.txt"` {
+		panic("wrong error message without causes")
+	}
+}
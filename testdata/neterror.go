@@ -0,0 +1,30 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+type Err string
+
+const (
+	ErrReset   = Err("net:temp connection reset by {{peer string %s}}")
+	ErrTimeout = Err("net:temp,timeout nowrap:request timed out")
+)
+
+var (
+	_ net.Error = (*errReset)(nil)
+	_ net.Error = (*errTimeout)(nil)
+)
+
+func main() {
+	err := fmt.Errorf("fetching: %w", newErrReset("10.0.0.1").Wrap(newErrTimeout()))
+	var ne net.Error
+	if !errors.As(err, &ne) || !ne.Temporary() || ne.Timeout() {
+		panic("ErrReset not a temporary net.Error")
+	}
+	if !errors.As(errors.Unwrap(ne), &ne) || !ne.Timeout() {
+		panic("ErrTimeout not a timeout net.Error")
+	}
+}
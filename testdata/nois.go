@@ -0,0 +1,24 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Err string
+
+const (
+	ErrOpen = Err("failed to open {{file string %q}}")
+	ErrRead = Err("nowrap:failed to read")
+)
+
+func main() {
+	err := newErrOpen("config.json").Wrap(newErrRead())
+	if got, expected := err.Error(), `failed to open "config.json": failed to read`; got != expected {
+		panic(fmt.Sprintf("got %q, expected %q", got, expected))
+	}
+	var read *errRead
+	if !errors.As(err, &read) {
+		panic("cause not in chain")
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Err string
+
+const (
+	ErrDial  = Err("failed to dial {{addr string %s}} attempt {{retries opt int %d}}")
+	ErrQuery = Err("nowrap:query {{q string %q}} after {{elapsed opt string %s}} ({{rows int %d}} rows)")
+	ErrCode  = Err("nowrap:failed on {{host string %s}} with {{code opt string %s /^[A-Z]{3}$/}}")
+)
+
+func check(got, expected string) {
+	if got != expected {
+		panic(fmt.Sprintf("got %q, expected %q", got, expected))
+	}
+}
+
+func main() {
+	check(newErrDial("localhost", 0).Error(), "failed to dial localhost")
+	check(newErrDial("localhost", 3).Error(), "failed to dial localhost attempt 3")
+	err := newErrDial("localhost", 2).Wrap(errors.New("refused"))
+	check(err.Error(), "failed to dial localhost attempt 2: refused")
+	check(fmt.Sprintf("%s", err), "failed to dial localhost attempt 2")
+	check(newErrQuery("select", "", 0).Error(), `query "select" (0 rows)`)
+	check(newErrQuery("select", "5s", 10).Error(), `query "select" after 5s (10 rows)`)
+	// The pattern is only checked when the optional field is given.
+	check(newErrCode("localhost", "").Error(), "failed on localhost")
+	check(newErrCode("localhost", "ABC").Error(), "failed on localhost with ABC")
+}
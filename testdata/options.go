@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Err string
+
+const (
+	ErrOpen  = Err("open {{file string %q}} failed {{reason opt string %s}} after {{retries opt int %d}}")
+	ErrWrite = Err("wrap:write {{file string %q}} at {{offset opt int64 %d}}")
+	ErrCode  = Err("nowrap:failed on {{host string %s}} with {{code opt string %s /^[A-Z]{3}$/}}")
+)
+
+func check(got, expected string) {
+	if got != expected {
+		panic(fmt.Sprintf("got %q, expected %q", got, expected))
+	}
+}
+
+func main() {
+	check(newErrOpen("a.txt").Error(), `open "a.txt"`)
+	check(newErrOpen("a.txt", errOpenWithRetries(3)).Error(), `open "a.txt" after 3`)
+	check(newErrOpen("a.txt", errOpenWithReason("denied"), errOpenWithRetries(1)).Error(),
+		`open "a.txt" failed denied after 1`)
+	cause := errors.New("disk full")
+	err := newErrWrite("a.txt", cause, errWriteWithOffset(42))
+	check(err.Error(), `write "a.txt" at 42: disk full`)
+	if !errors.Is(err, cause) {
+		panic("cause not wrapped")
+	}
+	check(newErrWrite("a.txt", cause).Error(), `write "a.txt": disk full`)
+	// The pattern is only checked when the option is given.
+	check(newErrCode("localhost").Error(), "failed on localhost")
+	check(newErrCode("localhost", errCodeWithCode("ABC")).Error(), "failed on localhost with ABC")
+}
@@ -0,0 +1,14 @@
+package main
+
+import "time"
+
+type Err string
+
+const ErrTimeout = Err("timed out after {{d time.Duration %s}}")
+
+func main() {
+	e := newErrTimeout(2 * time.Second)
+	if e.Error() != "timed out after 2s" {
+		panic("wrong error message: " + e.Error())
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import "context"
+
+type Err string
+
+const (
+	ErrOpen   = Err("failed to open {{file string %q}}")
+	ErrCancel = Err("nowrap:canceled {{ctx string %s}} at {{e int %d}}")
+)
+
+type reqIDKey struct{}
+
+func main() {
+	ctx := context.WithValue(context.Background(), reqIDKey{}, "req-42")
+	if id := newErrOpenCtx(ctx, "filename.txt").RequestID(); id != "req-42" {
+		panic("wrong request ID: " + id)
+	}
+	if id := newErrOpen("filename.txt").RequestID(); id != "" {
+		panic("unexpected request ID: " + id)
+	}
+	// Fields can take the names of the identifiers of the constructor.
+	err := newErrCancelCtx(ctx, "upload", 3)
+	if msg := err.Error(); msg != "canceled upload at 3" {
+		panic("wrong message: " + msg)
+	}
+	if id := err.RequestID(); id != "req-42" {
+		panic("wrong request ID: " + id)
+	}
+}
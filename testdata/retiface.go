@@ -0,0 +1,30 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Err string
+
+const (
+	ErrOpen = Err("failed to open {{file string %q}}")
+	ErrRead = Err("nowrap:failed to read")
+)
+
+func main() {
+	var read error = newErrRead()
+	var open ErrWrapper = newErrOpen("data.txt")
+	err := fmt.Errorf("loading: %w", open.Wrap(read))
+	if !ErrOpen.IsIn(err) {
+		panic("ErrOpen not in chain")
+	}
+	var e *errOpen
+	if !errors.As(err, &e) || e.file != "data.txt" {
+		panic("ErrOpen not extracted from chain")
+	}
+	var r *errRead
+	if !errors.As(err, &r) {
+		panic("ErrRead not extracted from chain")
+	}
+}
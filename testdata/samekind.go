@@ -0,0 +1,27 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Err string
+
+const (
+	ErrOpen = Err("failed to open {{file string %q}}")
+	ErrRead = Err("failed to read {{file string %q}}")
+)
+
+func main() {
+	a := newErrOpen("a.txt")
+	b := fmt.Errorf("loading: %w", newErrOpen("b.txt").Wrap(errors.New("not found")))
+	if !sameKind(a, b) {
+		panic("errors of the same kind not matched")
+	}
+	if sameKind(a, newErrRead("a.txt")) {
+		panic("errors of different kinds matched")
+	}
+	if sameKind(a, errors.New("failed to open")) {
+		panic("error of another type matched")
+	}
+}
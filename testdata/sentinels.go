@@ -0,0 +1,30 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Err string
+
+const (
+	ErrEOF  = Err("nowrap:unexpected end of file")
+	ErrRead = Err("failed to read {{file string %q}}")
+)
+
+func read(file string) error {
+	return newErrRead(file).Wrap(errEOFInstance)
+}
+
+func main() {
+	err := read("data.txt")
+	if !errors.Is(err, errEOFInstance) {
+		panic("sentinel instance not in chain")
+	}
+	if !errors.Is(err, ErrEOF) || !errors.Is(err, ErrRead) {
+		panic("constants not matched")
+	}
+	if errors.Is(newErrRead("data.txt"), errEOFInstance) {
+		panic(fmt.Sprintf("unexpected sentinel in %v", newErrRead("data.txt")))
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Err string
+
+const (
+	ErrLoad  = Err("failed to load {{name string %s}}")
+	ErrOpen  = Err("failed to open {{file string %q}}")
+	ErrParse = Err("wrap:failed to parse line {{line int %d}}")
+)
+
+func main() {
+	base := errors.New("unexpected EOF")
+	err := newErrLoad("config").Wrap(newErrOpen("config.json").Wrap(newErrParse(3, base)))
+	if got, expected := err.Error(), `failed to load config: failed to open "config.json"`; got != expected {
+		panic(fmt.Sprintf("got %q, expected %q", got, expected))
+	}
+	expected := `failed to load config: failed to open "config.json": failed to parse line 3: unexpected EOF`
+	if got := fmt.Sprintf("%+v", err); got != expected {
+		panic(fmt.Sprintf("got %q, expected %q", got, expected))
+	}
+	if !ErrParse.IsIn(err) || !errors.Is(err, base) {
+		panic("causes not in chain")
+	}
+}
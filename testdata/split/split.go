@@ -0,0 +1,28 @@
+package main
+
+import "errors"
+
+type Err string
+
+type Code int
+
+const (
+	ErrOpen = Err("failed to open {{file string %q}}")
+	ErrRead = Err("nowrap:failed to read")
+)
+
+const (
+	CodeOK     Code = iota
+	ErrTimeout      //gorror: multiwrap:timed out
+)
+
+func main() {
+	cause := errors.New("cause")
+	e := newErrOpen("filename.txt").Wrap(newErrTimeout(cause))
+	if !ErrOpen.IsIn(e) || e.Error() != `failed to open "filename.txt": timed out: cause` {
+		panic("wrong error chain: " + e.Error())
+	}
+	if len(newErrRead().StackTrace()) == 0 {
+		panic("empty stack trace")
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import "strings"
+
+type Err string
+
+const ErrOpen = Err("failed to open {{file string %q}}")
+
+func openFile() *errOpen {
+	return newErrOpen("filename.txt")
+}
+
+func main() {
+	e := openFile()
+	st := e.StackTrace()
+	if len(st) == 0 {
+		panic("empty stack trace")
+	}
+	if !strings.HasSuffix(st[0].Function, ".openFile") {
+		panic("wrong top frame: " + st[0].Function)
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import "fmt"
+
+type Err string
+
+const (
+	ErrOpen = Err("failed to open {{file string %q}}")
+	ErrRead = Err("nowrap:failed to read")
+)
+
+var (
+	_ fmt.Stringer = (*errOpen)(nil)
+	_ fmt.Stringer = (*errRead)(nil)
+)
+
+func main() {
+	err := newErrOpen("config.json").Wrap(newErrRead())
+	if got, expected := err.(fmt.Stringer).String(), err.Error(); got != expected {
+		panic(fmt.Sprintf("got %q, expected %q", got, expected))
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import "errors"
+
+type Err string
+
+const ErrClose = Err("failed to close {{file string %q}}")
+
+func main() {
+	cause := errors.New("cause")
+	first := errors.New("first suppressed")
+	second := errors.New("second suppressed")
+	e := newErrClose("filename.txt")
+	e.AddSuppressed(first)
+	e.AddSuppressed(second)
+	if !errors.Is(e, first) || !errors.Is(e, second) {
+		panic("suppressed errors not found")
+	}
+	e.Wrap(cause)
+	for _, target := range []error{cause, first, second} {
+		if !errors.Is(e, target) {
+			panic("not found: " + target.Error())
+		}
+	}
+}
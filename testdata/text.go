@@ -0,0 +1,23 @@
+package main
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+)
+
+type Err string
+
+const ErrOpen = Err("failed to open {{file string %q}}")
+
+var _ encoding.TextMarshaler = (*errOpen)(nil)
+
+func main() {
+	text, err := newErrOpen("data.txt").Wrap(errors.New("not found")).(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		panic(err)
+	}
+	if expected := `failed to open "data.txt": not found`; string(text) != expected {
+		panic(fmt.Sprintf("got %q, expected %q", text, expected))
+	}
+}
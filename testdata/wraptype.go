@@ -0,0 +1,22 @@
+package main
+
+import "errors"
+
+type Err string
+
+const ErrOpen = Err("failed to open {{file string %q}}")
+
+// _errWrap collides with the default name of the type holding the cause.
+type _errWrap struct{}
+
+func main() {
+	inner := errors.New("inner error")
+	e := newErrOpen("data.txt").Wrap(inner)
+	if !errors.Is(e, inner) {
+		panic("inner not in error")
+	}
+	if e.Error() != `failed to open "data.txt": inner error` {
+		panic("unexpected message " + e.Error())
+	}
+	_ = _errWrap{}
+}
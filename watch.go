@@ -0,0 +1,92 @@
+// (c) Copyright 2021, Gorror Authors.
+//
+// Licensed under the terms of the GNU GPL License version 3.
+
+package main
+
+import (
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long to wait for further changes before regenerating, so that bursts of
+// writes (e.g. from editors saving files) cause a single regeneration.
+const watchDebounce = 200 * time.Millisecond
+
+// watch monitors the Go files of the sources given as args, which are in dir, and calls
+// regenerate whenever one of them changes. Changes to the output files are ignored, regenerate
+// returns the new output files and the number of generated errors. Failed regenerations are
+// logged, keeping the previous outputs until the sources are fixed. It returns only on failure.
+func watch(dir string, args []string, outputs []string, regenerate func() ([]string, int, error)) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Fatalf("watching %s: %s", dir, err)
+	}
+	defer watcher.Close()
+	if err := watcher.Add(dir); err != nil {
+		log.Fatalf("watching %s: %s", dir, err)
+	}
+	log.Printf("watching %s for changes", dir)
+
+	var timer <-chan time.Time
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op != fsnotify.Chmod && isWatched(event.Name, args, outputs) {
+				timer = time.After(watchDebounce)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("watching %s: %s", dir, err)
+		case <-timer:
+			timer = nil
+			names, n, err := regenerate()
+			if err != nil {
+				log.Printf("%s: %s", time.Now().Format("15:04:05"), err)
+				continue
+			}
+			outputs = names
+			log.Printf("%s: regenerated %d errors", time.Now().Format("15:04:05"), n)
+		}
+	}
+}
+
+// isWatched reports whether a change to the named file requires regenerating: it has to be a Go
+// file other than the outputs and, when the sources are given as files, one of them.
+func isWatched(name string, args []string, outputs []string) bool {
+	if filepath.Ext(name) != ".go" {
+		return false
+	}
+	for _, output := range outputs {
+		if sameFile(name, output) {
+			return false
+		}
+	}
+	if len(args) == 1 {
+		// A directory that cannot be inspected anymore is treated as a file.
+		if isDir, err := isDirectory(args[0]); err == nil && isDir {
+			return true
+		}
+	}
+	for _, arg := range args {
+		if sameFile(name, arg) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameFile reports whether two paths refer to the same file, comparing their absolute form.
+func sameFile(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
@@ -0,0 +1,38 @@
+// (c) Copyright 2021, Gorror Authors.
+//
+// Licensed under the terms of the GNU GPL License version 3.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsWatched(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "errors.go")
+	if err := os.WriteFile(source, []byte("package test\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outputs := []string{filepath.Join(dir, "err_def.go")}
+	for _, test := range []struct {
+		name     string
+		args     []string
+		expected bool
+	}{
+		{source, []string{dir}, true},
+		{filepath.Join(dir, "other.go"), []string{dir}, true},
+		{filepath.Join(dir, "err_def.go"), []string{dir}, false},
+		{filepath.Join(dir, "notes.txt"), []string{dir}, false},
+		{source, []string{source}, true},
+		{filepath.Join(dir, "other.go"), []string{source}, false},
+		// Editors may remove the file while saving it.
+		{filepath.Join(dir, "gone.go"), []string{filepath.Join(dir, "gone.go")}, true},
+	} {
+		if got := isWatched(test.name, test.args, outputs); got != test.expected {
+			t.Errorf("%s with args %v: got %t, expected %t", test.name, test.args, got, test.expected)
+		}
+	}
+}